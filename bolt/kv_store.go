@@ -0,0 +1,391 @@
+package bolt
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+	"github.com/areller/turing"
+	"go.etcd.io/bbolt"
+)
+
+var (
+	stringsBucket = []byte("strings")
+	hashesBucket = []byte("hashes")
+	expiryBucket = []byte("expiry")
+)
+
+type KVStore struct {
+	db *bbolt.DB
+	reapInterval time.Duration
+	clock turing.Clock
+	closeChan chan struct{}
+	closeOnce sync.Once
+	wg sync.WaitGroup
+}
+
+func (ks *KVStore) convertError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch err {
+	case turing.KeyNotExistsError, turing.WrongTypeError, turing.InvalidTypeError:
+		return err
+	case bbolt.ErrDatabaseNotOpen:
+		return turing.ConnectionDroppedError
+	}
+	return turing.GeneralError
+}
+
+func (ks *KVStore) expired(tx *bbolt.Tx, key []byte) bool {
+	v := tx.Bucket(expiryBucket).Get(key)
+	if v == nil {
+		return false
+	}
+	return ks.clock.Now().UnixNano() >= int64(binary.BigEndian.Uint64(v))
+}
+
+func (ks *KVStore) isString(tx *bbolt.Tx, key []byte) bool {
+	return tx.Bucket(stringsBucket).Get(key) != nil
+}
+
+func (ks *KVStore) isHash(tx *bbolt.Tx, key []byte) bool {
+	return tx.Bucket(hashesBucket).Bucket(key) != nil
+}
+
+func (ks *KVStore) exists(tx *bbolt.Tx, key []byte) bool {
+	return (ks.isString(tx, key) || ks.isHash(tx, key)) && !ks.expired(tx, key)
+}
+
+func (ks *KVStore) purge(tx *bbolt.Tx, key []byte) error {
+	if err := tx.Bucket(stringsBucket).Delete(key); err != nil {
+		return err
+	}
+	if ks.isHash(tx, key) {
+		if err := tx.Bucket(hashesBucket).DeleteBucket(key); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(expiryBucket).Delete(key)
+}
+
+func (ks *KVStore) purgeExpired(tx *bbolt.Tx, key []byte) error {
+	if ks.expired(tx, key) {
+		return ks.purge(tx, key)
+	}
+	return nil
+}
+
+func (ks *KVStore) hash(tx *bbolt.Tx, key []byte) (*bbolt.Bucket, error) {
+	if err := ks.purgeExpired(tx, key); err != nil {
+		return nil, err
+	}
+	if ks.isString(tx, key) {
+		return nil, turing.WrongTypeError
+	}
+	return tx.Bucket(hashesBucket).CreateBucketIfNotExists(key)
+}
+
+func (ks *KVStore) Set(key string, value interface{}) error {
+	s, err := turing.FormatValue(value)
+	if err != nil {
+		return err
+	}
+
+	return ks.convertError(ks.db.Update(func (tx *bbolt.Tx) error {
+		k := []byte(key)
+		if err := ks.purge(tx, k); err != nil {
+			return err
+		}
+		return tx.Bucket(stringsBucket).Put(k, []byte(s))
+	}))
+}
+
+func (ks *KVStore) Get(key string) (string, error) {
+	var res string
+	err := ks.db.View(func (tx *bbolt.Tx) error {
+		k := []byte(key)
+		if !ks.exists(tx, k) {
+			return turing.KeyNotExistsError
+		} else if !ks.isString(tx, k) {
+			return turing.WrongTypeError
+		}
+		res = string(tx.Bucket(stringsBucket).Get(k))
+		return nil
+	})
+	return res, ks.convertError(err)
+}
+
+func (ks *KVStore) Delete(keys ...string) (int, error) {
+	c := 0
+	err := ks.db.Update(func (tx *bbolt.Tx) error {
+		for _, key := range keys {
+			k := []byte(key)
+			if ks.exists(tx, k) {
+				c++
+			}
+			if err := ks.purge(tx, k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, ks.convertError(err)
+	}
+	return c, nil
+}
+
+func (ks *KVStore) Exists(keys ...string) (int, error) {
+	c := 0
+	err := ks.db.View(func (tx *bbolt.Tx) error {
+		for _, key := range keys {
+			if ks.exists(tx, []byte(key)) {
+				c++
+			}
+		}
+		return nil
+	})
+	return c, ks.convertError(err)
+}
+
+func (ks *KVStore) HSet(key string, field string, value interface{}) error {
+	return ks.HSetMany(key, map[string]interface{}{
+		field: value,
+	})
+}
+
+// HSetMany with no fields is a no-op, it doesn't create the hash.
+func (ks *KVStore) HSetMany(key string, kv map[string]interface{}) error {
+	if len(kv) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(kv))
+	for field, value := range kv {
+		s, err := turing.FormatValue(value)
+		if err != nil {
+			return err
+		}
+		values[field] = s
+	}
+
+	return ks.convertError(ks.db.Update(func (tx *bbolt.Tx) error {
+		b, err := ks.hash(tx, []byte(key))
+		if err != nil {
+			return err
+		}
+		for field, value := range values {
+			if err := b.Put([]byte(field), []byte(value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func (ks *KVStore) HGet(key string, field string) (string, error) {
+	var res string
+	err := ks.db.View(func (tx *bbolt.Tx) error {
+		k := []byte(key)
+		if !ks.exists(tx, k) {
+			return turing.KeyNotExistsError
+		} else if !ks.isHash(tx, k) {
+			return turing.WrongTypeError
+		}
+		v := tx.Bucket(hashesBucket).Bucket(k).Get([]byte(field))
+		if v == nil {
+			return turing.KeyNotExistsError
+		}
+		res = string(v)
+		return nil
+	})
+	return res, ks.convertError(err)
+}
+
+func (ks *KVStore) HGetAll(key string) (map[string]string, error) {
+	res := make(map[string]string)
+	err := ks.db.View(func (tx *bbolt.Tx) error {
+		k := []byte(key)
+		if !ks.exists(tx, k) {
+			return nil
+		} else if !ks.isHash(tx, k) {
+			return turing.WrongTypeError
+		}
+		return tx.Bucket(hashesBucket).Bucket(k).ForEach(func (field []byte, value []byte) error {
+			res[string(field)] = string(value)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, ks.convertError(err)
+	}
+	return res, nil
+}
+
+func (ks *KVStore) HDelete(key string, fields ...string) (int, error) {
+	c := 0
+	err := ks.db.Update(func (tx *bbolt.Tx) error {
+		k := []byte(key)
+		if !ks.exists(tx, k) {
+			return nil
+		} else if !ks.isHash(tx, k) {
+			return turing.WrongTypeError
+		}
+
+		b := tx.Bucket(hashesBucket).Bucket(k)
+		for _, field := range fields {
+			if b.Get([]byte(field)) != nil {
+				c++
+				if err := b.Delete([]byte(field)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if first, _ := b.Cursor().First(); first == nil {
+			return ks.purge(tx, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, ks.convertError(err)
+	}
+	return c, nil
+}
+
+func (ks *KVStore) Expire(key string, expiry time.Duration) error {
+	return ks.convertError(ks.db.Update(func (tx *bbolt.Tx) error {
+		k := []byte(key)
+		if !ks.exists(tx, k) {
+			return turing.KeyNotExistsError
+		}
+
+		v := make([]byte, 8)
+		binary.BigEndian.PutUint64(v, uint64(ks.clock.Now().Add(expiry).UnixNano()))
+		return tx.Bucket(expiryBucket).Put(k, v)
+	}))
+}
+
+func (ks *KVStore) Ping() error {
+	return ks.convertError(ks.db.View(func (tx *bbolt.Tx) error {
+		return nil
+	}))
+}
+
+func (ks *KVStore) Reap() (int, error) {
+	c := 0
+	err := ks.db.Update(func (tx *bbolt.Tx) error {
+		var expired [][]byte
+		err := tx.Bucket(expiryBucket).ForEach(func (key []byte, value []byte) error {
+			if ks.expired(tx, key) {
+				expired = append(expired, append([]byte(nil), key...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range expired {
+			if err := ks.purge(tx, key); err != nil {
+				return err
+			}
+		}
+		c = len(expired)
+		return nil
+	})
+	return c, ks.convertError(err)
+}
+
+func (ks *KVStore) Snapshot(w io.Writer) (int64, error) {
+	var n int64
+	err := ks.db.View(func (tx *bbolt.Tx) error {
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+func (ks *KVStore) Checkpoint(path string) error {
+	return ks.db.View(func (tx *bbolt.Tx) error {
+		return tx.CopyFile(path, 0600)
+	})
+}
+
+func (ks *KVStore) run() {
+	defer ks.wg.Done()
+	timer := ks.clock.NewTimer(ks.reapInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <- ks.closeChan:
+			return
+		case <- timer.C():
+			if _, err := ks.Reap(); err != nil {
+				turing.Log.WithError(err).Error("Could not reap expired keys")
+			}
+			timer.Reset(ks.reapInterval)
+		}
+	}
+}
+
+func (ks *KVStore) Close() {
+	ks.closeOnce.Do(func () {
+		close(ks.closeChan)
+		ks.wg.Wait()
+		ks.db.Close()
+	})
+}
+
+type Option func (ks *KVStore)
+
+// WithClock sets the clock that deadlines are stamped and reaped with.
+func WithClock(clock turing.Clock) Option {
+	return func (ks *KVStore) {
+		ks.clock = clock
+	}
+}
+
+// NewKVStore opens the store at path. Expired keys are removed every
+// reapInterval, a reapInterval of zero or less turns the background reaping
+// off and leaves it to Reap.
+func NewKVStore(path string, reapInterval time.Duration, opts ...Option) (*KVStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{
+		Timeout: time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func (tx *bbolt.Tx) error {
+		for _, name := range [][]byte{ stringsBucket, hashesBucket, expiryBucket } {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	ks := &KVStore{
+		db: db,
+		reapInterval: reapInterval,
+		clock: turing.SystemClock,
+		closeChan: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(ks)
+	}
+	if reapInterval > 0 {
+		ks.wg.Add(1)
+		go ks.run()
+	}
+	return ks, nil
+}
\ No newline at end of file
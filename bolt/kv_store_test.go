@@ -0,0 +1,159 @@
+package bolt
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"github.com/areller/turing"
+	"github.com/areller/turing/kvtest"
+	"github.com/areller/turing/tester"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/bbolt"
+)
+
+func newTestStore(t *testing.T, path string) *KVStore {
+	store, err := NewKVStore(path, 10 * time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestKVStore(t *testing.T) {
+	// The suite closes its stores once the test finishes, remove the directory
+	// after them.
+	dir, _ := ioutil.TempDir("", "turing_bolt")
+	t.Cleanup(func () {
+		os.RemoveAll(dir)
+	})
+
+	i := 0
+	kvtest.Run(t, func () turing.KVStore {
+		i++
+		return newTestStore(t, filepath.Join(dir, fmt.Sprintf("%d.db", i)))
+	}, nil)
+}
+
+func TestKVStorePersistence(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "turing_bolt")
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.db")
+	store := newTestStore(t, path)
+	store.Set("a", "1")
+	store.HSet("b", "field", "2")
+	store.Close()
+
+	store = newTestStore(t, path)
+	defer store.Close()
+
+	val, err := store.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+
+	val, err = store.HGet("b", "field")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", val)
+}
+
+func TestKVStoreReaping(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "turing_bolt")
+	defer os.RemoveAll(dir)
+
+	store := newTestStore(t, filepath.Join(dir, "store.db"))
+	defer store.Close()
+
+	store.Set("a", "1")
+	store.Expire("a", time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	n, err := store.Reap()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	store.db.View(func (tx *bbolt.Tx) error {
+		assert.Nil(t, tx.Bucket(stringsBucket).Get([]byte("a")))
+		assert.Nil(t, tx.Bucket(expiryBucket).Get([]byte("a")))
+		return nil
+	})
+}
+
+func TestKVStoreWithoutReaping(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "turing_bolt")
+	defer os.RemoveAll(dir)
+
+	store, err := NewKVStore(filepath.Join(dir, "store.db"), 0)
+	assert.Nil(t, err)
+	defer store.Close()
+
+	store.Set("a", "1")
+	store.Expire("a", time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	_, err = store.Get("a")
+	assert.Equal(t, turing.KeyNotExistsError, err)
+	n, err := store.Reap()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestKVStoreClock(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "turing_bolt")
+	defer os.RemoveAll(dir)
+
+	clock := tester.NewVirtualClock(time.Unix(0, 0))
+	store, err := NewKVStore(filepath.Join(dir, "store.db"), time.Minute, WithClock(clock))
+	assert.Nil(t, err)
+	defer store.Close()
+	clock.WaitForTimers(1)
+
+	store.Set("a", "1")
+	store.Expire("a", time.Minute)
+	clock.Advance(59 * time.Second)
+	n, _ := store.Exists("a")
+	assert.Equal(t, 1, n)
+
+	clock.Advance(time.Minute)
+	store.db.View(func (tx *bbolt.Tx) error {
+		assert.Nil(t, tx.Bucket(stringsBucket).Get([]byte("a")))
+		return nil
+	})
+}
+
+func TestKVStoreCheckpoint(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "turing_bolt")
+	defer os.RemoveAll(dir)
+
+	store := newTestStore(t, filepath.Join(dir, "store.db"))
+	defer store.Close()
+
+	store.Set("a", "1")
+	checkpoint := filepath.Join(dir, "checkpoint.db")
+	assert.Nil(t, store.Checkpoint(checkpoint))
+	store.Set("a", "2")
+
+	var buf bytes.Buffer
+	n, err := store.Snapshot(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	restored := newTestStore(t, checkpoint)
+	defer restored.Close()
+
+	val, err := restored.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+	assert.Nil(t, ioutil.WriteFile(snapshot, buf.Bytes(), 0600))
+	fromSnapshot := newTestStore(t, snapshot)
+	defer fromSnapshot.Close()
+
+	val, err = fromSnapshot.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", val)
+}
\ No newline at end of file
@@ -0,0 +1,47 @@
+package turing
+
+import (
+	"time"
+)
+
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (st systemTimer) C() <-chan time.Time {
+	return st.timer.C
+}
+
+func (st systemTimer) Stop() bool {
+	return st.timer.Stop()
+}
+
+func (st systemTimer) Reset(d time.Duration) bool {
+	return st.timer.Reset(d)
+}
+
+type systemClock struct {
+}
+
+func (sc systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (sc systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{
+		timer: time.NewTimer(d),
+	}
+}
+
+var SystemClock Clock = systemClock{}
\ No newline at end of file
@@ -8,6 +8,7 @@ type DecodedKV struct {
 type EncodedKV struct {
 	Key []byte
 	Value []byte
+	Headers []Header
 }
 
 type Codec interface {
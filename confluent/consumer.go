@@ -3,6 +3,7 @@ package confluent
 import (
 	"github.com/areller/turing"
 	"strings"
+	"sync/atomic"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 )
 
@@ -37,10 +38,13 @@ type Consumer struct {
 	assignChan chan assignment
 	partitionEventChan chan turing.PartitionEvent
 	messageEventChan chan turing.MessageEvent
+	connected int32
+	assigned int32
 }
 
 func (c *Consumer) handleAssignedPartitions(parts kafka.AssignedPartitions) {
 	c.assignChan = make(chan assignment, len(parts.Partitions))
+	atomic.StoreInt32(&c.assigned, int32(len(parts.Partitions)))
 
 	partsMap := make(map[string]map[int64]kafka.TopicPartition)
 	for _, p := range parts.Partitions {
@@ -88,6 +92,7 @@ func (c *Consumer) handleAssignedPartitions(parts kafka.AssignedPartitions) {
 }
 
 func (c *Consumer) handleRevokedPartitions(parts kafka.RevokedPartitions) {
+	atomic.StoreInt32(&c.assigned, 0)
 	for _, p := range parts.Partitions {
 		c.partitionEventChan <- turing.PartitionEvent{
 			Type: turing.PartitionDestroyed,
@@ -100,12 +105,21 @@ func (c *Consumer) handleRevokedPartitions(parts kafka.RevokedPartitions) {
 }
 
 func (c *Consumer) handleMessage(msg *kafka.Message) {
+	var headers []turing.Header
+	for _, h := range msg.Headers {
+		headers = append(headers, turing.Header{
+			Key: h.Key,
+			Value: h.Value,
+		})
+	}
+
 	c.messageEventChan <- turing.MessageEvent{
 		Topic: *msg.TopicPartition.Topic,
 		PartitionId: int64(msg.TopicPartition.Partition),
 		Offset: int64(msg.TopicPartition.Offset),
 		Key: msg.Key,
 		Value: msg.Value,
+		Headers: headers,
 	}
 }
 
@@ -117,6 +131,26 @@ func (c *Consumer) handlePartitionEOF(eof kafka.PartitionEOF) {
 	}
 }
 
+func (c *Consumer) handleError(err kafka.Error) {
+	if err.Code() == kafka.ErrAllBrokersDown || err.Code() == kafka.ErrTransport {
+		atomic.StoreInt32(&c.connected, 0)
+	}
+
+	turing.Log.WithError(err).Error("confluent consumer: received an error from kafka")
+}
+
+func (c *Consumer) HealthCheck() turing.HealthCheck {
+	return func () error {
+		if atomic.LoadInt32(&c.connected) == 0 {
+			return turing.NotConnectedError
+		} else if atomic.LoadInt32(&c.assigned) == 0 {
+			return turing.NotAssignedError
+		}
+
+		return nil
+	}
+}
+
 func (c *Consumer) PartitionEvent() <-chan turing.PartitionEvent {
 	return c.partitionEventChan
 }
@@ -136,13 +170,22 @@ func (c *Consumer) Assign(topic string, partition int64, offset int64) {
 func (c *Consumer) Commit(topic string, partition int64, offset int64) {
 	off, _ := kafka.NewOffset(offset)
 
-	c.cconsumer.CommitOffsets([]kafka.TopicPartition{
+	_, err := c.cconsumer.CommitOffsets([]kafka.TopicPartition{
 		kafka.TopicPartition{
 			Topic: c.topicsMap[topic],
 			Partition: int32(partition),
 			Offset: 1 + off,
 		},
 	})
+
+	if err != nil {
+		turing.GetMetrics().CommitFailed(topic, partition)
+		turing.Log.WithError(err).WithFields(turing.LogFields{
+			"topic": topic,
+			"partition": partition,
+			"offset": offset,
+		}).Error("confluent consumer: could not commit offset")
+	}
 }
 
 func (c *Consumer) Subscribe(topics []string) {
@@ -160,6 +203,10 @@ func (c *Consumer) Run() error {
 		case <- c.closeChan:
 			return nil
 		case ev := <- c.cconsumer.Events():
+			if _, ok := ev.(kafka.Error); !ok {
+				atomic.StoreInt32(&c.connected, 1)
+			}
+
 			switch e := ev.(type) {
 			case kafka.AssignedPartitions:
 				c.handleAssignedPartitions(e)
@@ -169,6 +216,8 @@ func (c *Consumer) Run() error {
 				c.handleMessage(e)
 			case kafka.PartitionEOF:
 				c.handlePartitionEOF(e)
+			case kafka.Error:
+				c.handleError(e)
 			}
 		}
 	}
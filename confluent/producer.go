@@ -1,9 +1,11 @@
 package confluent
 
 import (
+	"context"
 	"sync/atomic"
 	"sync"
 	"strings"
+	"time"
 	"github.com/areller/turing"
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 )
@@ -26,30 +28,175 @@ type Producer struct {
 	deliveryChan chan kafka.Event
 	closeChan chan struct{}
 	msgId int64
-	waitMap map[int64]*sync.WaitGroup
+	waitMap map[int64]chan error
+	waitMutex sync.Mutex
+	deliveries *deliveryWindow
+	clock turing.Clock
 }
 
-func (p *Producer) Send(topic string, key []byte, msg []byte) error {
+const deliveryWindowBuckets = 10
+
+const minDeliveryBucketSize = time.Millisecond
+
+type deliveryBucket struct {
+	start time.Time
+	delivered int64
+	failed int64
+}
+
+// deliveryWindow counts delivery outcomes over a fixed window of time, split
+// into buckets so that old outcomes drop out in steps.
+type deliveryWindow struct {
+	bucketSize time.Duration
+	minSamples int64
+	buckets [deliveryWindowBuckets]deliveryBucket
+	mutex sync.Mutex
+}
+
+func (dw *deliveryWindow) set(window time.Duration, minSamples int) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	dw.bucketSize = window / deliveryWindowBuckets
+	if dw.bucketSize < minDeliveryBucketSize {
+		dw.bucketSize = minDeliveryBucketSize
+	}
+	dw.minSamples = int64(minSamples)
+	dw.buckets = [deliveryWindowBuckets]deliveryBucket{}
+}
+
+func (dw *deliveryWindow) record(now time.Time, failed bool) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	start := now.Truncate(dw.bucketSize)
+	b := &dw.buckets[(start.UnixNano() / int64(dw.bucketSize)) % deliveryWindowBuckets]
+	if !b.start.Equal(start) {
+		*b = deliveryBucket{
+			start: start,
+		}
+	}
+
+	if failed {
+		b.failed++
+	} else {
+		b.delivered++
+	}
+}
+
+// errorRate returns the rate of failed deliveries within the window, or false
+// if there were too few deliveries to tell.
+func (dw *deliveryWindow) errorRate(now time.Time) (float64, bool) {
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+	oldest := now.Truncate(dw.bucketSize).Add(-dw.bucketSize * (deliveryWindowBuckets - 1))
+	var delivered, failed int64
+	for _, b := range dw.buckets {
+		if !b.start.Before(oldest) {
+			delivered += b.delivered
+			failed += b.failed
+		}
+	}
+
+	if delivered + failed == 0 || delivered + failed < dw.minSamples {
+		return 0, false
+	}
+	return float64(failed) / float64(delivered + failed), true
+}
+
+func newDeliveryWindow(window time.Duration, minSamples int) *deliveryWindow {
+	dw := &deliveryWindow{}
+	dw.set(window, minSamples)
+	return dw
+}
+
+func (p *Producer) release(id int64) chan error {
+	p.waitMutex.Lock()
+	defer p.waitMutex.Unlock()
+	done, ok := p.waitMap[id]
+	if !ok {
+		return nil
+	}
+
+	delete(p.waitMap, id)
+	p.wg.Done()
+	return done
+}
+
+func (p *Producer) produce(ctx context.Context, topic string, key []byte, msg []byte, headers []kafka.Header) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	id := atomic.AddInt64(&p.msgId, 1)
+	done := make(chan error, 1)
+
+	p.waitMutex.Lock()
+	p.waitMap[id] = done
+	p.wg.Add(1)
+	p.waitMutex.Unlock()
+
 	err := p.cproducer.Produce(&kafka.Message{
 		TopicPartition: kafka.TopicPartition{ Topic: &topic, Partition: kafka.PartitionAny },
 		Key: key,
 		Value: msg,
+		Headers: headers,
 		Opaque: id,
 	}, p.deliveryChan)
 
 	if err != nil {
+		p.release(id)
 		return err
 	}
 
-	p.wg.Add(1)
-	
-	var localWg sync.WaitGroup
-	localWg.Add(1)
-	p.waitMap[id] = &localWg
+	select {
+	case err = <- done:
+		return err
+	case <- ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Producer) Send(topic string, key []byte, msg []byte) error {
+	return p.produce(context.Background(), topic, key, msg, nil)
+}
+
+func (p *Producer) SendWithHeaders(topic string, key []byte, msg []byte, headers []turing.Header) error {
+	return p.SendContext(context.Background(), topic, key, msg, headers)
+}
+
+func (p *Producer) SendContext(ctx context.Context, topic string, key []byte, msg []byte, headers []turing.Header) error {
+	var kheaders []kafka.Header
+	for _, h := range headers {
+		kheaders = append(kheaders, kafka.Header{
+			Key: h.Key,
+			Value: h.Value,
+		})
+	}
+
+	return p.produce(ctx, topic, key, msg, kheaders)
+}
+
+// SetHealthWindow sets the window over which HealthCheck computes the error
+// rate, and the number of deliveries it needs within it to judge. Windows
+// shorter than 10ms are raised to 10ms.
+func (p *Producer) SetHealthWindow(window time.Duration, minSamples int) {
+	p.deliveries.set(window, minSamples)
+}
 
-	localWg.Wait()
-	return err
+// HealthCheck fails while the rate of failed deliveries within the health
+// window is above maxErrorRate.
+func (p *Producer) HealthCheck(maxErrorRate float64) turing.HealthCheck {
+	return func () error {
+		if rate, ok := p.deliveries.errorRate(p.clock.Now()); ok && rate > maxErrorRate {
+			return turing.DeliveryErrorRateError
+		}
+
+		return nil
+	}
+}
+
+// SetClock sets the clock deliveries are placed in the health window with.
+func (p *Producer) SetClock(clock turing.Clock) {
+	p.clock = clock
 }
 
 func (p *Producer) Close() {
@@ -63,12 +210,17 @@ func (p *Producer) Run() error {
 		case <-p.closeChan:
 			return nil
 		case e := <-p.deliveryChan:
-			msg := e.(*kafka.Message)
-			id := msg.Opaque.(int64)
-			localWg := p.waitMap[id]
-			delete(p.waitMap, id)
-			localWg.Done()
-			p.wg.Done()
+			msg, ok := e.(*kafka.Message)
+			if !ok {
+				continue
+			}
+
+			p.deliveries.record(p.clock.Now(), msg.TopicPartition.Error != nil)
+
+			done := p.release(msg.Opaque.(int64))
+			if done != nil {
+				done <- msg.TopicPartition.Error
+			}
 		}
 	}
 }
@@ -88,6 +240,8 @@ func NewProducer(config ProducerConfig) *Producer {
 		msgId: 0,
 		deliveryChan: make(chan kafka.Event),
 		closeChan: make(chan struct{}),
-		waitMap: make(map[int64]*sync.WaitGroup),
+		waitMap: make(map[int64]chan error),
+		deliveries: newDeliveryWindow(time.Minute, 10),
+		clock: turing.SystemClock,
 	}
 }
\ No newline at end of file
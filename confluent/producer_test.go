@@ -0,0 +1,47 @@
+package confluent
+
+import (
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryWindow(t *testing.T) {
+	dw := newDeliveryWindow(10 * time.Second, 5)
+	start := time.Unix(1000, 0)
+
+	dw.record(start, true)
+	_, ok := dw.errorRate(start)
+	assert.False(t, ok)
+
+	for i := 0; i < 4; i++ {
+		dw.record(start.Add(time.Second), false)
+	}
+	rate, ok := dw.errorRate(start.Add(time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, 0.2, rate)
+
+	// Probing does not reset the window, the rate holds until it slides.
+	rate, _ = dw.errorRate(start.Add(9 * time.Second))
+	assert.Equal(t, 0.2, rate)
+
+	_, ok = dw.errorRate(start.Add(10 * time.Second))
+	assert.False(t, ok)
+	_, ok = dw.errorRate(start.Add(time.Hour))
+	assert.False(t, ok)
+}
+
+func TestDeliveryWindowMinimumSize(t *testing.T) {
+	dw := newDeliveryWindow(0, 1)
+	start := time.Unix(1000, 0)
+
+	assert.NotPanics(t, func () {
+		dw.record(start, true)
+	})
+	rate, ok := dw.errorRate(start.Add(9 * time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rate)
+
+	_, ok = dw.errorRate(start.Add(10 * time.Millisecond))
+	assert.False(t, ok)
+}
\ No newline at end of file
@@ -20,12 +20,18 @@ func (pe PartitionEvent) String() string {
 	return pe.Topic + "_" + strconv.FormatInt(pe.Id, 10)
 }
 
+type Header struct {
+	Key string
+	Value []byte
+}
+
 type MessageEvent struct {
 	Topic string
 	PartitionId int64
 	Offset int64
 	Key []byte
 	Value []byte
+	Headers []Header
 }
 
 func (me MessageEvent) PartitionString() string {
@@ -16,6 +16,23 @@ var (
 	GeneralError = errors.New("General error")
 	ConnectionDroppedError = errors.New("Connection dropped")
 	FatalError = errors.New("Fatal error")
+	NotRunningError = errors.New("Not running")
+	NotConnectedError = errors.New("Not connected")
+	NotAssignedError = errors.New("No partitions are assigned")
+	PartitionStuckError = errors.New("Partition is stuck")
+	DeliveryErrorRateError = errors.New("Delivery error rate is too high")
+	ShutdownTimeoutError = errors.New("Shutdown timed out")
+	RestartIntensityError = errors.New("Restart intensity exceeded")
+	LockNotHeldError = errors.New("Lock is not held")
+	TxFailedError = errors.New("Transaction failed, watched keys were modified")
+	InvalidValueError = errors.New("Value could not be decoded")
+	ReadOnlyError = errors.New("Store is read-only")
+	InvalidSnapshotError = errors.New("Snapshot is invalid or of an unsupported version")
+	CircuitOpenError = errors.New("Circuit breaker is open")
+	InvalidRecordingError = errors.New("Recording is invalid or of an unsupported version")
+	IrreversibleGatewayError = errors.New("Key gateway has no reverse mapping")
+	NoAddressError = errors.New("No address to listen on")
+	NotProcessedError = errors.New("Message was not processed in time")
 )
 
 func UnrecongnizableError(err error) bool {
@@ -29,5 +46,22 @@ func UnrecongnizableError(err error) bool {
 		   err != KeyNotExistsError &&
 		   err != GeneralError &&
 		   err != ConnectionDroppedError &&
-		   err != FatalError
+		   err != FatalError &&
+		   err != NotRunningError &&
+		   err != NotConnectedError &&
+		   err != NotAssignedError &&
+		   err != PartitionStuckError &&
+		   err != DeliveryErrorRateError &&
+		   err != ShutdownTimeoutError &&
+		   err != RestartIntensityError &&
+		   err != LockNotHeldError &&
+		   err != TxFailedError &&
+		   err != InvalidValueError &&
+		   err != ReadOnlyError &&
+		   err != InvalidSnapshotError &&
+		   err != CircuitOpenError &&
+		   err != InvalidRecordingError &&
+		   err != IrreversibleGatewayError &&
+		   err != NoAddressError &&
+		   err != NotProcessedError
 }
\ No newline at end of file
@@ -2,13 +2,14 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"github.com/areller/turing"
 	"github.com/areller/turing/confluent"
 )
 
 func HandleTestMessages(context turing.SimpleProcessorContext, msg turing.DecodedKV) (error, bool) {
 	val := msg.Value.(string)
-	fmt.Println("New Message %s: %s", msg.Key, val)
+	fmt.Printf("New Message %s: %s\n", msg.Key, val)
 	return nil, true
 }
 
@@ -28,5 +29,5 @@ func main() {
 														},
 													})
 
-	turing.RunInProcess(simpleProcessor)
+	os.Exit(turing.ExitCode(turing.RunInProcess(simpleProcessor)))
 }
\ No newline at end of file
@@ -0,0 +1,145 @@
+package turing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type HealthCheck func () error
+
+type Pinger interface {
+	Ping() error
+}
+
+type namedHealthCheck struct {
+	name string
+	check HealthCheck
+}
+
+type HealthReport struct {
+	Healthy bool `json:"healthy"`
+	Checks map[string]string `json:"checks"`
+}
+
+type HealthServer struct {
+	mutex sync.RWMutex
+	liveness []namedHealthCheck
+	readiness []namedHealthCheck
+	server *http.Server
+	shutdownTimeout time.Duration
+}
+
+func runHealthChecks(checks []namedHealthCheck) HealthReport {
+	report := HealthReport{
+		Healthy: true,
+		Checks: make(map[string]string),
+	}
+
+	for _, c := range checks {
+		err := c.check()
+		if err != nil {
+			report.Healthy = false
+			report.Checks[c.name] = err.Error()
+		} else {
+			report.Checks[c.name] = "ok"
+		}
+	}
+
+	return report
+}
+
+func (hs *HealthServer) serveChecks(checks func () []namedHealthCheck) http.HandlerFunc {
+	return func (w http.ResponseWriter, r *http.Request) {
+		report := runHealthChecks(checks())
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+func (hs *HealthServer) AddLivenessCheck(name string, check HealthCheck) {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	hs.liveness = append(hs.liveness, namedHealthCheck{
+		name: name,
+		check: check,
+	})
+}
+
+func (hs *HealthServer) AddReadinessCheck(name string, check HealthCheck) {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+	hs.readiness = append(hs.readiness, namedHealthCheck{
+		name: name,
+		check: check,
+	})
+}
+
+func (hs *HealthServer) Liveness() HealthReport {
+	hs.mutex.RLock()
+	defer hs.mutex.RUnlock()
+	return runHealthChecks(hs.liveness)
+}
+
+func (hs *HealthServer) Readiness() HealthReport {
+	hs.mutex.RLock()
+	defer hs.mutex.RUnlock()
+	return runHealthChecks(hs.readiness)
+}
+
+func (hs *HealthServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", hs.serveChecks(func () []namedHealthCheck {
+		hs.mutex.RLock()
+		defer hs.mutex.RUnlock()
+		return hs.liveness
+	}))
+	mux.HandleFunc("/readyz", hs.serveChecks(func () []namedHealthCheck {
+		hs.mutex.RLock()
+		defer hs.mutex.RUnlock()
+		return hs.readiness
+	}))
+	return mux
+}
+
+func (hs *HealthServer) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), hs.shutdownTimeout)
+	defer cancel()
+	hs.server.Shutdown(ctx)
+}
+
+func (hs *HealthServer) Run() error {
+	err := hs.server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+
+	return err
+}
+
+func NewHealthServer(address string) *HealthServer {
+	hs := &HealthServer{
+		shutdownTimeout: 5 * time.Second,
+	}
+	hs.server = &http.Server{
+		Addr: address,
+		Handler: hs.Handler(),
+	}
+	return hs
+}
+
+func KVStoreHealthCheck(store KVStore) HealthCheck {
+	return func () error {
+		if pinger, ok := store.(Pinger); ok {
+			return pinger.Ping()
+		}
+
+		_, err := store.Exists("turing_health")
+		return err
+	}
+}
\ No newline at end of file
@@ -0,0 +1,84 @@
+package turing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthServer(t *testing.T) {
+	hs := NewHealthServer(":0")
+	hs.AddLivenessCheck("alive", func () error {
+		return nil
+	})
+	hs.AddReadinessCheck("consumer", func () error {
+		return NotAssignedError
+	})
+
+	server := httptest.NewServer(hs.Handler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/healthz")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(server.URL + "/readyz")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+	var report HealthReport
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&report))
+	assert.False(t, report.Healthy)
+	assert.Equal(t, NotAssignedError.Error(), report.Checks["consumer"])
+}
+
+func TestProcessorHealthCheck(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	consumer := NewConsumerMock()
+	sp, _ := NewSimpleProcessor(consumer, nil, []SimpleProcessorTopicDefinition{
+		SimpleProcessorTopicDefinition{
+			Name: "myTopic",
+			Codec: new(StringCodec),
+			Handler: func (ctx SimpleProcessorContext, msg DecodedKV) (error, bool) {
+				close(started)
+				<- release
+				return nil, true
+			},
+		},
+	})
+
+	check := sp.HealthCheck(10 * time.Millisecond)
+	assert.Equal(t, NotRunningError, check())
+
+	go sp.Run()
+
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 0,
+	})
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 1,
+	})
+	consumer.CreateMessageEvent(MessageEvent{
+		Topic: "myTopic",
+		PartitionId: 0,
+		Offset: 0,
+		Key: []byte("myKey"),
+		Value: []byte("My Message"),
+	})
+
+	<- started
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, PartitionStuckError, check())
+
+	// Metrics are global, the processor must be gone before the next test.
+	close(release)
+	sp.Close()
+}
\ No newline at end of file
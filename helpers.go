@@ -1,20 +1,30 @@
 package turing
 
 import (
+	"fmt"
+	"math/rand"
+	"os"
 	"time"
 )
 
-func tryWithTimeout(ts time.Duration, what func()) bool {
+func newInstanceId() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), rand.Int63())
+}
+
+func tryWithTimeout(clock Clock, ts time.Duration, what func()) bool {
 	done := make(chan struct{})
 	go func() {
 		what()
 		close(done)
 	}()
 
+	timer := clock.NewTimer(ts)
 	select {
-	case <-time.After(ts):
+	case <-timer.C():
 		return false
 	case <-done:
+		timer.Stop()
 		return true
 	}
 }
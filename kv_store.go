@@ -1,6 +1,10 @@
 package turing
 
 import (
+	"context"
+	"encoding"
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -15,4 +19,163 @@ type KVStore interface {
 	HGetAll(key string) (map[string]string, error)
 	HDelete(key string, fields ...string) (int, error)
 	Expire(key string, expiry time.Duration) error
+}
+
+type ZMember struct {
+	Member string
+	Score float64
+}
+
+type ExtendedKVStore interface {
+	KVStore
+	Incr(key string) (int64, error)
+	IncrBy(key string, value int64) (int64, error)
+	HIncrBy(key string, field string, value int64) (int64, error)
+	SAdd(key string, members ...string) (int, error)
+	SRem(key string, members ...string) (int, error)
+	SIsMember(key string, member string) (bool, error)
+	SMembers(key string) ([]string, error)
+	ZAdd(key string, members ...ZMember) (int, error)
+	ZRangeByScore(key string, min float64, max float64) ([]ZMember, error)
+	ZRem(key string, members ...string) (int, error)
+	LPush(key string, values ...string) (int, error)
+	RPop(key string) (string, error)
+	LRange(key string, start int64, stop int64) ([]string, error)
+}
+
+type SetNXKVStore interface {
+	SetNX(key string, value interface{}, expiry time.Duration) (bool, error)
+}
+
+type LeaseKVStore interface {
+	SetNXKVStore
+	RenewLease(key string, token string, ttl time.Duration) (bool, error)
+	ReleaseLease(key string, token string) (bool, error)
+}
+
+func FormatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", InvalidTypeError
+}
+
+type ContextKVStore interface {
+	KVStore
+	WithContext(ctx context.Context) KVStore
+}
+
+func KVStoreWithContext(ctx context.Context, store KVStore) KVStore {
+	if cstore, ok := store.(ContextKVStore); ok {
+		return cstore.WithContext(ctx)
+	}
+
+	return &contextKVStore{
+		store: store,
+		ctx: ctx,
+	}
+}
+
+type contextKVStore struct {
+	store KVStore
+	ctx context.Context
+}
+
+func (cks *contextKVStore) Set(key string, value interface{}) error {
+	if err := cks.ctx.Err(); err != nil {
+		return err
+	}
+	return cks.store.Set(key, value)
+}
+
+func (cks *contextKVStore) Get(key string) (string, error) {
+	if err := cks.ctx.Err(); err != nil {
+		return "", err
+	}
+	return cks.store.Get(key)
+}
+
+func (cks *contextKVStore) Delete(keys ...string) (int, error) {
+	if err := cks.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cks.store.Delete(keys...)
+}
+
+func (cks *contextKVStore) Exists(keys ...string) (int, error) {
+	if err := cks.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cks.store.Exists(keys...)
+}
+
+func (cks *contextKVStore) HSet(key string, field string, value interface{}) error {
+	if err := cks.ctx.Err(); err != nil {
+		return err
+	}
+	return cks.store.HSet(key, field, value)
+}
+
+func (cks *contextKVStore) HSetMany(key string, kv map[string]interface{}) error {
+	if err := cks.ctx.Err(); err != nil {
+		return err
+	}
+	return cks.store.HSetMany(key, kv)
+}
+
+func (cks *contextKVStore) HGet(key string, field string) (string, error) {
+	if err := cks.ctx.Err(); err != nil {
+		return "", err
+	}
+	return cks.store.HGet(key, field)
+}
+
+func (cks *contextKVStore) HGetAll(key string) (map[string]string, error) {
+	if err := cks.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return cks.store.HGetAll(key)
+}
+
+func (cks *contextKVStore) HDelete(key string, fields ...string) (int, error) {
+	if err := cks.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cks.store.HDelete(key, fields...)
+}
+
+func (cks *contextKVStore) Expire(key string, expiry time.Duration) error {
+	if err := cks.ctx.Err(); err != nil {
+		return err
+	}
+	return cks.store.Expire(key, expiry)
+}
+
+func (cks *contextKVStore) WithContext(ctx context.Context) KVStore {
+	return &contextKVStore{
+		store: cks.store,
+		ctx: ctx,
+	}
 }
\ No newline at end of file
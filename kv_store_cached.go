@@ -0,0 +1,418 @@
+package turing
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type CacheStats struct {
+	Hits int64
+	Misses int64
+	Evictions int64
+	Invalidations int64
+	Size int
+}
+
+type cacheEntry struct {
+	key string
+	value string
+	isHash bool
+	fields map[string]string
+	complete bool
+	expires time.Time
+}
+
+// lruCache is shared between a CachedKVStore and the views returned by
+// WithContext. version is bumped on every invalidation, a value read from
+// the underlying store is only cached if no invalidation happened while it
+// was being fetched. deadlines holds the expiry set through Expire, a key is
+// never cached past the point where the underlying store drops it.
+type lruCache struct {
+	capacity int
+	ttl time.Duration
+	ttlFunc func (key string) time.Duration
+	entries map[string]*list.Element
+	deadlines map[string]time.Time
+	clock Clock
+	lru *list.List
+	version uint64
+	stats CacheStats
+	subs []Subscription
+	mutex sync.Mutex
+}
+
+func (lc *lruCache) expiry(key string) time.Time {
+	ttl := lc.ttl
+	if lc.ttlFunc != nil {
+		ttl = lc.ttlFunc(key)
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = lc.clock.Now().Add(ttl)
+	}
+
+	if deadline, ok := lc.deadlines[key]; ok && (expires.IsZero() || deadline.Before(expires)) {
+		expires = deadline
+	}
+	return expires
+}
+
+func (lc *lruCache) setDeadlineLocked(key string, deadline time.Time) {
+	if len(lc.deadlines) >= lc.capacity {
+		now := lc.clock.Now()
+		for k, d := range lc.deadlines {
+			if now.After(d) {
+				delete(lc.deadlines, k)
+			}
+		}
+	}
+	lc.deadlines[key] = deadline
+}
+
+func (lc *lruCache) removeElement(elem *list.Element) {
+	lc.lru.Remove(elem)
+	delete(lc.entries, elem.Value.(*cacheEntry).key)
+}
+
+func (lc *lruCache) getLocked(key string) *cacheEntry {
+	elem, ok := lc.entries[key]
+	if !ok {
+		return nil
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && lc.clock.Now().After(entry.expires) {
+		lc.removeElement(elem)
+		return nil
+	}
+
+	lc.lru.MoveToFront(elem)
+	return entry
+}
+
+func (lc *lruCache) putLocked(entry *cacheEntry) {
+	if elem, ok := lc.entries[entry.key]; ok {
+		lc.removeElement(elem)
+	}
+
+	entry.expires = lc.expiry(entry.key)
+	lc.entries[entry.key] = lc.lru.PushFront(entry)
+	for lc.lru.Len() > lc.capacity {
+		lc.removeElement(lc.lru.Back())
+		lc.stats.Evictions++
+	}
+}
+
+func (lc *lruCache) record(operation string, hit bool) {
+	if hit {
+		lc.stats.Hits++
+	} else {
+		lc.stats.Misses++
+	}
+	GetMetrics().KVStoreCacheLookup(operation, hit)
+}
+
+func (lc *lruCache) invalidateLocked(keys ...string) {
+	lc.version++
+	for _, key := range keys {
+		if elem, ok := lc.entries[key]; ok {
+			lc.removeElement(elem)
+			lc.stats.Invalidations++
+		}
+	}
+}
+
+func (lc *lruCache) invalidate(keys ...string) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+	lc.invalidateLocked(keys...)
+}
+
+func (lc *lruCache) purge() {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+	lc.version++
+	lc.stats.Invalidations += int64(len(lc.entries))
+	lc.entries = make(map[string]*list.Element)
+	lc.lru.Init()
+}
+
+func (lc *lruCache) watch(sub Subscription) {
+	for event := range sub.Events() {
+		lc.invalidate(event.Key)
+	}
+
+	// Notifications may have been missed, nothing in the cache can be trusted.
+	lc.purge()
+}
+
+type CachedKVStore struct {
+	store KVStore
+	cache *lruCache
+}
+
+func (cks *CachedKVStore) SetTTLFunc(ttlFunc func (key string) time.Duration) {
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	cks.cache.ttlFunc = ttlFunc
+}
+
+// SetClock sets the clock that cache entries expire by, it should match the
+// clock of the underlying store.
+func (cks *CachedKVStore) SetClock(clock Clock) {
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	cks.cache.clock = clock
+}
+
+func (cks *CachedKVStore) InvalidateOnChange(pattern string) error {
+	watchStore, ok := cks.store.(WatchKVStore)
+	if !ok {
+		return InvalidTypeError
+	}
+
+	sub, err := watchStore.WatchPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	cks.cache.mutex.Lock()
+	cks.cache.subs = append(cks.cache.subs, sub)
+	cks.cache.mutex.Unlock()
+
+	go cks.cache.watch(sub)
+	return nil
+}
+
+func (cks *CachedKVStore) Invalidate(keys ...string) {
+	cks.cache.invalidate(keys...)
+}
+
+func (cks *CachedKVStore) Stats() CacheStats {
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	stats := cks.cache.stats
+	stats.Size = len(cks.cache.entries)
+	return stats
+}
+
+func (cks *CachedKVStore) WithContext(ctx context.Context) KVStore {
+	return &CachedKVStore{
+		store: KVStoreWithContext(ctx, cks.store),
+		cache: cks.cache,
+	}
+}
+
+func (cks *CachedKVStore) Set(key string, value interface{}) error {
+	formatted, err := FormatValue(value)
+	if err != nil {
+		return err
+	}
+
+	cks.cache.mutex.Lock()
+	cks.cache.invalidateLocked(key)
+	version := cks.cache.version
+	cks.cache.mutex.Unlock()
+
+	err = cks.store.Set(key, value)
+
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	if err == nil {
+		delete(cks.cache.deadlines, key)
+	}
+	if err == nil && cks.cache.version == version {
+		cks.cache.putLocked(&cacheEntry{
+			key: key,
+			value: formatted,
+		})
+	} else {
+		cks.cache.invalidateLocked(key)
+	}
+	return err
+}
+
+func (cks *CachedKVStore) Get(key string) (string, error) {
+	cks.cache.mutex.Lock()
+	if entry := cks.cache.getLocked(key); entry != nil && !entry.isHash {
+		cks.cache.record("get", true)
+		cks.cache.mutex.Unlock()
+		return entry.value, nil
+	}
+	cks.cache.record("get", false)
+	version := cks.cache.version
+	cks.cache.mutex.Unlock()
+
+	value, err := cks.store.Get(key)
+	if err != nil {
+		return value, err
+	}
+
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	if cks.cache.version == version {
+		cks.cache.putLocked(&cacheEntry{
+			key: key,
+			value: value,
+		})
+	}
+	return value, nil
+}
+
+func (cks *CachedKVStore) Delete(keys ...string) (int, error) {
+	n, err := cks.store.Delete(keys...)
+
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	cks.cache.invalidateLocked(keys...)
+	if err == nil {
+		for _, key := range keys {
+			delete(cks.cache.deadlines, key)
+		}
+	}
+	return n, err
+}
+
+func (cks *CachedKVStore) Exists(keys ...string) (int, error) {
+	return cks.store.Exists(keys...)
+}
+
+func (cks *CachedKVStore) HSet(key string, field string, value interface{}) error {
+	err := cks.store.HSet(key, field, value)
+	cks.cache.invalidate(key)
+	return err
+}
+
+func (cks *CachedKVStore) HSetMany(key string, kv map[string]interface{}) error {
+	err := cks.store.HSetMany(key, kv)
+	cks.cache.invalidate(key)
+	return err
+}
+
+func (cks *CachedKVStore) HGet(key string, field string) (string, error) {
+	cks.cache.mutex.Lock()
+	if entry := cks.cache.getLocked(key); entry != nil && entry.isHash {
+		if value, ok := entry.fields[field]; ok {
+			cks.cache.record("hget", true)
+			cks.cache.mutex.Unlock()
+			return value, nil
+		} else if entry.complete {
+			cks.cache.record("hget", true)
+			cks.cache.mutex.Unlock()
+			return "", KeyNotExistsError
+		}
+	}
+	cks.cache.record("hget", false)
+	version := cks.cache.version
+	cks.cache.mutex.Unlock()
+
+	value, err := cks.store.HGet(key, field)
+	if err != nil {
+		return value, err
+	}
+
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	if cks.cache.version == version {
+		entry := cks.cache.getLocked(key)
+		if entry == nil || !entry.isHash {
+			entry = &cacheEntry{
+				key: key,
+				isHash: true,
+				fields: make(map[string]string),
+			}
+			cks.cache.putLocked(entry)
+		}
+		entry.fields[field] = value
+	}
+	return value, nil
+}
+
+func (cks *CachedKVStore) HGetAll(key string) (map[string]string, error) {
+	cks.cache.mutex.Lock()
+	if entry := cks.cache.getLocked(key); entry != nil && entry.isHash && entry.complete {
+		cks.cache.record("hgetall", true)
+		res := make(map[string]string, len(entry.fields))
+		for k, v := range entry.fields {
+			res[k] = v
+		}
+		cks.cache.mutex.Unlock()
+		return res, nil
+	}
+	cks.cache.record("hgetall", false)
+	version := cks.cache.version
+	cks.cache.mutex.Unlock()
+
+	res, err := cks.store.HGetAll(key)
+	if err != nil || len(res) == 0 {
+		return res, err
+	}
+
+	fields := make(map[string]string, len(res))
+	for k, v := range res {
+		fields[k] = v
+	}
+
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	if cks.cache.version == version {
+		cks.cache.putLocked(&cacheEntry{
+			key: key,
+			isHash: true,
+			fields: fields,
+			complete: true,
+		})
+	}
+	return res, nil
+}
+
+func (cks *CachedKVStore) HDelete(key string, fields ...string) (int, error) {
+	n, err := cks.store.HDelete(key, fields...)
+	cks.cache.invalidate(key)
+	return n, err
+}
+
+func (cks *CachedKVStore) Expire(key string, expiry time.Duration) error {
+	err := cks.store.Expire(key, expiry)
+
+	cks.cache.mutex.Lock()
+	defer cks.cache.mutex.Unlock()
+	cks.cache.invalidateLocked(key)
+	if err == nil {
+		cks.cache.setDeadlineLocked(key, cks.cache.clock.Now().Add(expiry))
+	}
+	return err
+}
+
+func (cks *CachedKVStore) Close() {
+	cks.cache.mutex.Lock()
+	subs := cks.cache.subs
+	cks.cache.subs = nil
+	cks.cache.mutex.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+// NewCachedKVStore caches up to capacity keys of store for ttl each, it panics
+// if capacity is not positive.
+func NewCachedKVStore(store KVStore, capacity int, ttl time.Duration) *CachedKVStore {
+	if capacity <= 0 {
+		panic("turing: non-positive capacity for NewCachedKVStore")
+	}
+
+	return &CachedKVStore{
+		store: store,
+		cache: &lruCache{
+			capacity: capacity,
+			ttl: ttl,
+			entries: make(map[string]*list.Element),
+			deadlines: make(map[string]time.Time),
+			clock: SystemClock,
+			lru: list.New(),
+		},
+	}
+}
\ No newline at end of file
@@ -0,0 +1,187 @@
+package turing
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedKVStoreHitsAndMisses(t *testing.T) {
+	backing := NewKVStoreMemory()
+	defer backing.Close()
+	store := NewCachedKVStore(backing, 10, time.Minute)
+
+	backing.Set("a", "1")
+	backing.HSetMany("h", map[string]interface{}{ "x": "1", "y": "2" })
+
+	for i := 0; i < 3; i++ {
+		val, err := store.Get("a")
+		assert.Nil(t, err)
+		assert.Equal(t, "1", val)
+	}
+
+	all, err := store.HGetAll("h")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{ "x": "1", "y": "2" }, all)
+	val, err := store.HGet("h", "y")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", val)
+	_, err = store.HGet("h", "z")
+	assert.Equal(t, KeyNotExistsError, err)
+
+	_, err = store.Get("missing")
+	assert.Equal(t, KeyNotExistsError, err)
+
+	stats := store.Stats()
+	assert.Equal(t, int64(4), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+	assert.Equal(t, 2, stats.Size)
+
+	// Writes bypassing the cache are not visible until invalidated.
+	backing.Set("a", "2")
+	val, _ = store.Get("a")
+	assert.Equal(t, "1", val)
+	store.Invalidate("a")
+	val, _ = store.Get("a")
+	assert.Equal(t, "2", val)
+}
+
+func TestCachedKVStoreWriteThrough(t *testing.T) {
+	backing := NewKVStoreMemory()
+	defer backing.Close()
+	store := NewCachedKVStore(backing, 10, time.Minute)
+
+	assert.Nil(t, store.Set("a", 42))
+	val, err := store.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "42", val)
+	assert.Equal(t, int64(1), store.Stats().Hits)
+
+	store.HSet("h", "x", "1")
+	store.HGet("h", "x")
+	assert.Nil(t, store.HSet("h", "x", "2"))
+	val, _ = store.HGet("h", "x")
+	assert.Equal(t, "2", val)
+
+	store.HDelete("h", "x")
+	_, err = store.HGet("h", "x")
+	assert.Equal(t, KeyNotExistsError, err)
+
+	store.Delete("a")
+	_, err = store.Get("a")
+	assert.Equal(t, KeyNotExistsError, err)
+}
+
+func TestCachedKVStoreEvictionAndTTL(t *testing.T) {
+	backing := NewKVStoreMemory()
+	defer backing.Close()
+	store := NewCachedKVStore(backing, 2, time.Minute)
+	store.SetTTLFunc(func (key string) time.Duration {
+		if key == "short" {
+			return 10 * time.Millisecond
+		}
+		return time.Minute
+	})
+
+	store.Set("a", "1")
+	store.Set("b", "1")
+	store.Get("a")
+	store.Set("c", "1")
+
+	stats := store.Stats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Size)
+
+	store.Get("b")
+	assert.Equal(t, int64(1), store.Stats().Misses)
+	store.Get("a")
+	assert.Equal(t, int64(2), store.Stats().Misses)
+
+	store.Set("short", "1")
+	backing.Set("short", "2")
+	time.Sleep(20 * time.Millisecond)
+	val, _ := store.Get("short")
+	assert.Equal(t, "2", val)
+}
+
+func TestCachedKVStoreExpire(t *testing.T) {
+	backing := NewKVStoreMemory()
+	defer backing.Close()
+
+	for _, ttl := range []time.Duration{ 0, time.Minute } {
+		store := NewCachedKVStore(backing, 10, ttl)
+		assert.Nil(t, store.Set("a", "1"))
+		assert.Nil(t, store.Expire("a", 20 * time.Millisecond))
+		val, err := store.Get("a")
+		assert.Nil(t, err)
+		assert.Equal(t, "1", val)
+
+		time.Sleep(60 * time.Millisecond)
+		n, _ := backing.Exists("a")
+		assert.Equal(t, 0, n)
+		_, err = store.Get("a")
+		assert.Equal(t, KeyNotExistsError, err)
+
+		// Set clears the expiry, as it does in the underlying store.
+		assert.Nil(t, store.Set("a", "2"))
+		time.Sleep(30 * time.Millisecond)
+		val, err = store.Get("a")
+		assert.Nil(t, err)
+		assert.Equal(t, "2", val)
+		store.Delete("a")
+	}
+}
+
+func TestCachedKVStoreInvalidateOnChange(t *testing.T) {
+	backing := NewKVStoreMemory()
+	defer backing.Close()
+	store := NewCachedKVStore(backing, 10, time.Minute)
+	defer store.Close()
+
+	assert.Equal(t, InvalidTypeError, NewCachedKVStore(stubKVStore{}, 10, time.Minute).InvalidateOnChange("*"))
+	assert.Nil(t, store.InvalidateOnChange("*"))
+
+	backing.Set("a", "1")
+	store.Get("a")
+	backing.Set("a", "2")
+
+	assert.Eventually(t, func () bool {
+		val, _ := store.Get("a")
+		return val == "2"
+	}, time.Second, 5 * time.Millisecond)
+}
+
+func TestCachedKVStoreInvalidateOnRestore(t *testing.T) {
+	backing := NewKVStoreMemory()
+	defer backing.Close()
+	store := NewCachedKVStore(backing, 10, time.Minute)
+	defer store.Close()
+	assert.Nil(t, store.InvalidateOnChange("*"))
+
+	backing.Set("a", "1")
+	var buf bytes.Buffer
+	_, err := backing.Snapshot(&buf)
+	assert.Nil(t, err)
+
+	backing.Set("a", "2")
+	backing.Set("b", "1")
+	store.Get("a")
+	store.Get("b")
+	assert.Nil(t, backing.Restore(&buf))
+
+	assert.Eventually(t, func () bool {
+		a, _ := store.Get("a")
+		_, err := store.Get("b")
+		return a == "1" && err == KeyNotExistsError
+	}, time.Second, 5 * time.Millisecond)
+}
+
+func TestCachedKVStoreRejectsNonPositiveCapacity(t *testing.T) {
+	assert.Panics(t, func () {
+		NewCachedKVStore(stubKVStore{}, 0, time.Minute)
+	})
+	assert.Panics(t, func () {
+		NewCachedKVStore(stubKVStore{}, -1, time.Minute)
+	})
+}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package turing
+
+import (
+	"context"
+	"time"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type InstrumentedKVStore struct {
+	store KVStore
+	ctx context.Context
+}
+
+func (iks *InstrumentedKVStore) begin(operation string) func (err error) {
+	start := time.Now()
+	_, span := startSpan(iks.ctx, "kvstore " + operation, trace.SpanKindClient,
+		attribute.String("db.operation", operation))
+
+	return func (err error) {
+		endSpan(span, err)
+		if err == KeyNotExistsError {
+			err = nil
+		}
+		GetMetrics().KVStoreOperation(operation, time.Since(start), err)
+	}
+}
+
+func (iks *InstrumentedKVStore) WithContext(ctx context.Context) KVStore {
+	return &InstrumentedKVStore{
+		store: KVStoreWithContext(ctx, iks.store),
+		ctx: ctx,
+	}
+}
+
+func (iks *InstrumentedKVStore) Set(key string, value interface{}) error {
+	done := iks.begin("set")
+	err := iks.store.Set(key, value)
+	done(err)
+	return err
+}
+
+func (iks *InstrumentedKVStore) Get(key string) (string, error) {
+	done := iks.begin("get")
+	res, err := iks.store.Get(key)
+	done(err)
+	return res, err
+}
+
+func (iks *InstrumentedKVStore) Delete(keys ...string) (int, error) {
+	done := iks.begin("delete")
+	res, err := iks.store.Delete(keys...)
+	done(err)
+	return res, err
+}
+
+func (iks *InstrumentedKVStore) Exists(keys ...string) (int, error) {
+	done := iks.begin("exists")
+	res, err := iks.store.Exists(keys...)
+	done(err)
+	return res, err
+}
+
+func (iks *InstrumentedKVStore) HSet(key string, field string, value interface{}) error {
+	done := iks.begin("hset")
+	err := iks.store.HSet(key, field, value)
+	done(err)
+	return err
+}
+
+func (iks *InstrumentedKVStore) HSetMany(key string, kv map[string]interface{}) error {
+	done := iks.begin("hsetmany")
+	err := iks.store.HSetMany(key, kv)
+	done(err)
+	return err
+}
+
+func (iks *InstrumentedKVStore) HGet(key string, field string) (string, error) {
+	done := iks.begin("hget")
+	res, err := iks.store.HGet(key, field)
+	done(err)
+	return res, err
+}
+
+func (iks *InstrumentedKVStore) HGetAll(key string) (map[string]string, error) {
+	done := iks.begin("hgetall")
+	res, err := iks.store.HGetAll(key)
+	done(err)
+	return res, err
+}
+
+func (iks *InstrumentedKVStore) HDelete(key string, fields ...string) (int, error) {
+	done := iks.begin("hdelete")
+	res, err := iks.store.HDelete(key, fields...)
+	done(err)
+	return res, err
+}
+
+func (iks *InstrumentedKVStore) Expire(key string, expiry time.Duration) error {
+	done := iks.begin("expire")
+	err := iks.store.Expire(key, expiry)
+	done(err)
+	return err
+}
+
+func InstrumentKVStore(store KVStore) *InstrumentedKVStore {
+	return &InstrumentedKVStore{
+		store: store,
+		ctx: context.Background(),
+	}
+}
\ No newline at end of file
@@ -1,14 +1,25 @@
 package turing
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
 	"time"
 	"sync"
 )
 
+const memoryExpiryInterval = 5 * time.Second
+
 type KVStoreMemory struct {
 	kv map[string]interface{}
 	ex map[string]time.Time
 	closeChan chan struct{}
+	bus *memoryEventBus
+	clock Clock
+	unrestored string
+	seq uint64
+	versions map[string]uint64
+	watching map[string]int
 	rw sync.RWMutex
 }
 
@@ -24,6 +35,18 @@ type genericValue struct {
 	value interface{}
 }
 
+type setValue struct {
+	members map[string]struct{}
+}
+
+type sortedSetValue struct {
+	scores map[string]float64
+}
+
+type listValue struct {
+	items []string
+}
+
 func (kvs *KVStoreMemory) isString(key string) bool {
 	_, ok := kvs.kv[key].(stringValue)
 	return ok
@@ -39,8 +62,50 @@ func (kvs *KVStoreMemory) isGeneric(key string) bool {
 	return ok
 }
 
+func (kvs *KVStoreMemory) isSet(key string) bool {
+	_, ok := kvs.kv[key].(setValue)
+	return ok
+}
+
+func (kvs *KVStoreMemory) isSortedSet(key string) bool {
+	_, ok := kvs.kv[key].(sortedSetValue)
+	return ok
+}
+
+func (kvs *KVStoreMemory) isList(key string) bool {
+	_, ok := kvs.kv[key].(listValue)
+	return ok
+}
+
+func (kvs *KVStoreMemory) purgeExpired(key string) {
+	if !kvs.exists(key) {
+		_, ok := kvs.kv[key]
+		delete(kvs.kv, key)
+		delete(kvs.ex, key)
+		if ok {
+			kvs.changedLocked(KeyExpireEvent, key)
+		}
+	}
+}
+
+// touchLocked gives key a new version, which is how Watch detects writes.
+// Versions of deleted keys are only kept while someone watches them.
+func (kvs *KVStoreMemory) touchLocked(key string) {
+	kvs.seq++
+	if _, ok := kvs.kv[key]; ok || kvs.watching[key] > 0 {
+		kvs.versions[key] = kvs.seq
+	} else {
+		delete(kvs.versions, key)
+	}
+}
+
+func (kvs *KVStoreMemory) changedLocked(event KeyEventType, key string) {
+	kvs.touchLocked(key)
+	kvs.bus.publish(event, key)
+}
+
 func (kvs *KVStoreMemory) hashKeyExists(key string, field string) bool {
-	_, ok := kvs.kv[key].(hashMapValue).kvMap[key]
+	_, ok := kvs.kv[key].(hashMapValue).kvMap[field]
 	return ok
 }
 
@@ -58,67 +123,140 @@ func (kvs *KVStoreMemory) convertToStringMap(m map[string]interface{}) map[strin
 func (kvs *KVStoreMemory) exists(key string) bool {
 	_, ok := kvs.kv[key]
 	t, ok2 := kvs.ex[key]
-	return ok && (!ok2 || time.Now().Before(t))
+	return ok && (!ok2 || kvs.clock.Now().Before(t))
 }
 
 func (kvs *KVStoreMemory) deleteExpired() {
 	kvs.rw.Lock()
 	defer kvs.rw.Unlock()
 	for k, v := range kvs.ex {
-		if time.Now().After(v) {
+		if kvs.clock.Now().After(v) {
 			_, ok := kvs.kv[k]
 			if ok {
 				delete(kvs.kv, k)
+				kvs.changedLocked(KeyExpireEvent, k)
 			}
 			delete(kvs.ex, k)
 		}
 	}
 }
 
-func (kvs *KVStoreMemory) run() {
-	ticker := time.NewTicker(5 * time.Second)
+func (kvs *KVStoreMemory) run(timer Timer) {
+	defer timer.Stop()
 	for {
 		select {
 		case <- kvs.closeChan:
 			return
-		case <- ticker.C:
+		case <- timer.C():
 			kvs.deleteExpired()
+			timer.Reset(memoryExpiryInterval)
 		}
 	}
 }
 
+func (kvs *KVStoreMemory) setLocked(key string, value interface{}) error {
+	s, err := FormatValue(value)
+	if err != nil {
+		return err
+	}
+	kvs.kv[key] = stringValue{
+		value: s,
+	}
+	delete(kvs.ex, key)
+	kvs.changedLocked(KeySetEvent, key)
+	return nil
+}
+
 func (kvs *KVStoreMemory) Set(key string, value interface{}) error {
 	kvs.rw.Lock()
 	defer kvs.rw.Unlock()
-	if kvs.exists(key) && !kvs.isString(key) {
-		return WrongTypeError
+	return kvs.setLocked(key, value)
+}
+
+func (kvs *KVStoreMemory) SetNX(key string, value interface{}, expiry time.Duration) (bool, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	if kvs.exists(key) {
+		return false, nil
+	}
+	s, err := FormatValue(value)
+	if err != nil {
+		return false, err
 	}
 	kvs.kv[key] = stringValue{
-		value: value,
+		value: s,
 	}
-	return nil
+	if expiry > 0 {
+		kvs.ex[key] = kvs.clock.Now().Add(expiry)
+	} else {
+		delete(kvs.ex, key)
+	}
+	kvs.changedLocked(KeySetEvent, key)
+	return true, nil
 }
 
-func (kvs *KVStoreMemory) Get(key string) (string, error) {
-	kvs.rw.RLock()
-	defer kvs.rw.RUnlock()
+func (kvs *KVStoreMemory) ownsLease(key string, token string) bool {
+	if !kvs.exists(key) || !kvs.isString(key) {
+		return false
+	}
+	return kvs.kv[key].(stringValue).value == token
+}
+
+func (kvs *KVStoreMemory) RenewLease(key string, token string, ttl time.Duration) (bool, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	if !kvs.ownsLease(key, token) {
+		return false, nil
+	}
+	kvs.ex[key] = kvs.clock.Now().Add(ttl)
+	kvs.touchLocked(key)
+	return true, nil
+}
+
+func (kvs *KVStoreMemory) ReleaseLease(key string, token string) (bool, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	if !kvs.ownsLease(key, token) {
+		return false, nil
+	}
+	delete(kvs.kv, key)
+	delete(kvs.ex, key)
+	kvs.changedLocked(KeyDeleteEvent, key)
+	return true, nil
+}
+
+func (kvs *KVStoreMemory) getLocked(key string) (string, error) {
 	if !kvs.exists(key) {
 		return "", KeyNotExistsError
 	} else if !kvs.isString(key) {
 		return "", WrongTypeError
 	}
-	return kvs.kv[key].(stringValue).value.(string), nil
+	return fmt.Sprint(kvs.kv[key].(stringValue).value), nil
 }
 
-func (kvs *KVStoreMemory) Exists(key string) bool {
+func (kvs *KVStoreMemory) Get(key string) (string, error) {
 	kvs.rw.RLock()
 	defer kvs.rw.RUnlock()
-	return kvs.exists(key)
+	return kvs.getLocked(key)
 }
 
-func (kvs *KVStoreMemory) Delete(keys ...string) int {
-	kvs.rw.Lock()
-	defer kvs.rw.Unlock()
+func (kvs *KVStoreMemory) existsLocked(keys ...string) (int, error) {
+	c := 0
+	for _, key := range keys {
+		if kvs.exists(key) {
+			c++
+		}
+	}
+	return c, nil
+}
+
+func (kvs *KVStoreMemory) Exists(keys ...string) (int, error) {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	return kvs.existsLocked(keys...)
+}
+
+func (kvs *KVStoreMemory) deleteLocked(keys ...string) (int, error) {
 	c := 0
 	for _, key := range keys {
 		if kvs.exists(key) {
@@ -128,14 +266,24 @@ func (kvs *KVStoreMemory) Delete(keys ...string) int {
 				delete(kvs.ex, key)
 			}
 			delete(kvs.kv, key)
+			kvs.changedLocked(KeyDeleteEvent, key)
 		}
 	}
-	return c
+	return c, nil
 }
 
-func (kvs *KVStoreMemory) HSet(key string, field string, value interface{}) error {
+func (kvs *KVStoreMemory) Delete(keys ...string) (int, error) {
 	kvs.rw.Lock()
 	defer kvs.rw.Unlock()
+	return kvs.deleteLocked(keys...)
+}
+
+func (kvs *KVStoreMemory) hSetLocked(key string, field string, value interface{}) error {
+	s, err := FormatValue(value)
+	if err != nil {
+		return err
+	}
+	kvs.purgeExpired(key)
 	if kvs.exists(key) && !kvs.isHashMap(key) {
 		return WrongTypeError
 	} else if !kvs.exists(key) {
@@ -143,13 +291,31 @@ func (kvs *KVStoreMemory) HSet(key string, field string, value interface{}) erro
 			kvMap: make(map[string]interface{}),
 		}
 	}
-	kvs.kv[key].(hashMapValue).kvMap[field] = value
+	kvs.kv[key].(hashMapValue).kvMap[field] = s
+	kvs.changedLocked(KeySetEvent, key)
 	return nil
 }
 
-func (kvs *KVStoreMemory) HSetMany(key string, kv map[string]interface{}) error {
+func (kvs *KVStoreMemory) HSet(key string, field string, value interface{}) error {
 	kvs.rw.Lock()
 	defer kvs.rw.Unlock()
+	return kvs.hSetLocked(key, field, value)
+}
+
+func (kvs *KVStoreMemory) hSetManyLocked(key string, kv map[string]interface{}) error {
+	if len(kv) == 0 {
+		return nil
+	}
+
+	values := make(map[string]string, len(kv))
+	for k, v := range kv {
+		s, err := FormatValue(v)
+		if err != nil {
+			return err
+		}
+		values[k] = s
+	}
+	kvs.purgeExpired(key)
 	if kvs.exists(key) && !kvs.isHashMap(key) {
 		return WrongTypeError
 	} else if !kvs.exists(key) {
@@ -157,15 +323,20 @@ func (kvs *KVStoreMemory) HSetMany(key string, kv map[string]interface{}) error
 			kvMap: make(map[string]interface{}),
 		}
 	}
-	for k, v := range kv {
+	for k, v := range values {
 		kvs.kv[key].(hashMapValue).kvMap[k] = v
 	}
+	kvs.changedLocked(KeySetEvent, key)
 	return nil
 }
 
-func (kvs *KVStoreMemory) HGet(key string, field string) (string, error) {
-	kvs.rw.RLock()
-	defer kvs.rw.RUnlock()
+func (kvs *KVStoreMemory) HSetMany(key string, kv map[string]interface{}) error {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	return kvs.hSetManyLocked(key, kv)
+}
+
+func (kvs *KVStoreMemory) hGetLocked(key string, field string) (string, error) {
 	if !kvs.exists(key) {
 		return "", KeyNotExistsError
 	} else if !kvs.isHashMap(key) {
@@ -173,13 +344,17 @@ func (kvs *KVStoreMemory) HGet(key string, field string) (string, error) {
 	} else if !kvs.hashKeyExists(key, field) {
 		return "", KeyNotExistsError
 	} else {
-		return kvs.kv[key].(hashMapValue).kvMap[field].(string), nil
+		return fmt.Sprint(kvs.kv[key].(hashMapValue).kvMap[field]), nil
 	}
 }
 
-func (kvs *KVStoreMemory) HGetAll(key string) (map[string]string, error) {
+func (kvs *KVStoreMemory) HGet(key string, field string) (string, error) {
 	kvs.rw.RLock()
 	defer kvs.rw.RUnlock()
+	return kvs.hGetLocked(key, field)
+}
+
+func (kvs *KVStoreMemory) hGetAllLocked(key string) (map[string]string, error) {
 	if !kvs.exists(key) {
 		return make(map[string]string), nil
 	} else if !kvs.isHashMap(key) {
@@ -189,9 +364,13 @@ func (kvs *KVStoreMemory) HGetAll(key string) (map[string]string, error) {
 	}
 }
 
-func (kvs *KVStoreMemory) HDelete(key string, fields []string) (int, error) {
-	kvs.rw.Lock()
-	defer kvs.rw.Unlock()
+func (kvs *KVStoreMemory) HGetAll(key string) (map[string]string, error) {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	return kvs.hGetAllLocked(key)
+}
+
+func (kvs *KVStoreMemory) hDeleteLocked(key string, fields ...string) (int, error) {
 	if !kvs.exists(key) {
 		return 0, nil
 	} else if !kvs.isHashMap(key) {
@@ -208,27 +387,370 @@ func (kvs *KVStoreMemory) HDelete(key string, fields []string) (int, error) {
 		}
 		if len(m) == 0 {
 			delete(kvs.kv, key)
+			delete(kvs.ex, key)
+			kvs.changedLocked(KeyDeleteEvent, key)
+		} else if c > 0 {
+			kvs.changedLocked(KeySetEvent, key)
 		}
 		return c, nil
 	}
 }
 
-func (kvs *KVStoreMemory) Expire(key string, expiry time.Duration) error {
+func (kvs *KVStoreMemory) HDelete(key string, fields ...string) (int, error) {
 	kvs.rw.Lock()
 	defer kvs.rw.Unlock()
+	return kvs.hDeleteLocked(key, fields...)
+}
+
+func (kvs *KVStoreMemory) expireLocked(key string, expiry time.Duration) error {
 	if !kvs.exists(key) {
 		return KeyNotExistsError
 	}
-	kvs.ex[key] = time.Now().Add(expiry)
+	kvs.ex[key] = kvs.clock.Now().Add(expiry)
+	kvs.touchLocked(key)
 	return nil
 }
 
+func (kvs *KVStoreMemory) Expire(key string, expiry time.Duration) error {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	return kvs.expireLocked(key, expiry)
+}
+
+func (kvs *KVStoreMemory) Incr(key string) (int64, error) {
+	return kvs.IncrBy(key, 1)
+}
+
+func (kvs *KVStoreMemory) incrByLocked(key string, value int64) (int64, error) {
+	kvs.purgeExpired(key)
+	current := int64(0)
+	if kvs.exists(key) {
+		if !kvs.isString(key) {
+			return 0, WrongTypeError
+		}
+		n, err := strconv.ParseInt(fmt.Sprint(kvs.kv[key].(stringValue).value), 10, 64)
+		if err != nil {
+			return 0, GeneralError
+		}
+		current = n
+	}
+	current += value
+	kvs.kv[key] = stringValue{
+		value: strconv.FormatInt(current, 10),
+	}
+	kvs.changedLocked(KeySetEvent, key)
+	return current, nil
+}
+
+func (kvs *KVStoreMemory) IncrBy(key string, value int64) (int64, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	return kvs.incrByLocked(key, value)
+}
+
+func (kvs *KVStoreMemory) hIncrByLocked(key string, field string, value int64) (int64, error) {
+	kvs.purgeExpired(key)
+	if kvs.exists(key) && !kvs.isHashMap(key) {
+		return 0, WrongTypeError
+	} else if !kvs.exists(key) {
+		kvs.kv[key] = hashMapValue{
+			kvMap: make(map[string]interface{}),
+		}
+	}
+	m := kvs.kv[key].(hashMapValue).kvMap
+	current := int64(0)
+	if v, ok := m[field]; ok {
+		n, err := strconv.ParseInt(fmt.Sprint(v), 10, 64)
+		if err != nil {
+			return 0, GeneralError
+		}
+		current = n
+	}
+	current += value
+	m[field] = strconv.FormatInt(current, 10)
+	kvs.changedLocked(KeySetEvent, key)
+	return current, nil
+}
+
+func (kvs *KVStoreMemory) HIncrBy(key string, field string, value int64) (int64, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	return kvs.hIncrByLocked(key, field, value)
+}
+
+func (kvs *KVStoreMemory) SAdd(key string, members ...string) (int, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	kvs.purgeExpired(key)
+	if kvs.exists(key) && !kvs.isSet(key) {
+		return 0, WrongTypeError
+	} else if !kvs.exists(key) {
+		kvs.kv[key] = setValue{
+			members: make(map[string]struct{}),
+		}
+	}
+	c := 0
+	m := kvs.kv[key].(setValue).members
+	for _, member := range members {
+		if _, ok := m[member]; !ok {
+			c++
+			m[member] = struct{}{}
+		}
+	}
+	if c > 0 {
+		kvs.changedLocked(KeySetEvent, key)
+	}
+	return c, nil
+}
+
+func (kvs *KVStoreMemory) SRem(key string, members ...string) (int, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	if !kvs.exists(key) {
+		return 0, nil
+	} else if !kvs.isSet(key) {
+		return 0, WrongTypeError
+	}
+	c := 0
+	m := kvs.kv[key].(setValue).members
+	for _, member := range members {
+		if _, ok := m[member]; ok {
+			c++
+			delete(m, member)
+		}
+	}
+	if len(m) == 0 {
+		delete(kvs.kv, key)
+		delete(kvs.ex, key)
+		kvs.changedLocked(KeyDeleteEvent, key)
+	} else if c > 0 {
+		kvs.changedLocked(KeySetEvent, key)
+	}
+	return c, nil
+}
+
+func (kvs *KVStoreMemory) SIsMember(key string, member string) (bool, error) {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	if !kvs.exists(key) {
+		return false, nil
+	} else if !kvs.isSet(key) {
+		return false, WrongTypeError
+	}
+	_, ok := kvs.kv[key].(setValue).members[member]
+	return ok, nil
+}
+
+func (kvs *KVStoreMemory) SMembers(key string) ([]string, error) {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	if !kvs.exists(key) {
+		return []string{}, nil
+	} else if !kvs.isSet(key) {
+		return nil, WrongTypeError
+	}
+	members := make([]string, 0, len(kvs.kv[key].(setValue).members))
+	for member := range kvs.kv[key].(setValue).members {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (kvs *KVStoreMemory) ZAdd(key string, members ...ZMember) (int, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	kvs.purgeExpired(key)
+	if kvs.exists(key) && !kvs.isSortedSet(key) {
+		return 0, WrongTypeError
+	} else if !kvs.exists(key) {
+		kvs.kv[key] = sortedSetValue{
+			scores: make(map[string]float64),
+		}
+	}
+	c := 0
+	scores := kvs.kv[key].(sortedSetValue).scores
+	for _, member := range members {
+		if _, ok := scores[member.Member]; !ok {
+			c++
+		}
+		scores[member.Member] = member.Score
+	}
+	kvs.changedLocked(KeySetEvent, key)
+	return c, nil
+}
+
+func (kvs *KVStoreMemory) ZRangeByScore(key string, min float64, max float64) ([]ZMember, error) {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	if !kvs.exists(key) {
+		return []ZMember{}, nil
+	} else if !kvs.isSortedSet(key) {
+		return nil, WrongTypeError
+	}
+	members := []ZMember{}
+	for member, score := range kvs.kv[key].(sortedSetValue).scores {
+		if score >= min && score <= max {
+			members = append(members, ZMember{
+				Member: member,
+				Score: score,
+			})
+		}
+	}
+	sort.Slice(members, func (i, j int) bool {
+		if members[i].Score == members[j].Score {
+			return members[i].Member < members[j].Member
+		}
+		return members[i].Score < members[j].Score
+	})
+	return members, nil
+}
+
+func (kvs *KVStoreMemory) ZRem(key string, members ...string) (int, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	if !kvs.exists(key) {
+		return 0, nil
+	} else if !kvs.isSortedSet(key) {
+		return 0, WrongTypeError
+	}
+	c := 0
+	scores := kvs.kv[key].(sortedSetValue).scores
+	for _, member := range members {
+		if _, ok := scores[member]; ok {
+			c++
+			delete(scores, member)
+		}
+	}
+	if len(scores) == 0 {
+		delete(kvs.kv, key)
+		delete(kvs.ex, key)
+		kvs.changedLocked(KeyDeleteEvent, key)
+	} else if c > 0 {
+		kvs.changedLocked(KeySetEvent, key)
+	}
+	return c, nil
+}
+
+func (kvs *KVStoreMemory) LPush(key string, values ...string) (int, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	kvs.purgeExpired(key)
+	var items []string
+	if kvs.exists(key) {
+		if !kvs.isList(key) {
+			return 0, WrongTypeError
+		}
+		items = kvs.kv[key].(listValue).items
+	}
+	pushed := make([]string, 0, len(values) + len(items))
+	for i := len(values) - 1; i >= 0; i-- {
+		pushed = append(pushed, values[i])
+	}
+	pushed = append(pushed, items...)
+	kvs.kv[key] = listValue{
+		items: pushed,
+	}
+	kvs.changedLocked(KeySetEvent, key)
+	return len(pushed), nil
+}
+
+func (kvs *KVStoreMemory) RPop(key string) (string, error) {
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	if !kvs.exists(key) {
+		return "", KeyNotExistsError
+	} else if !kvs.isList(key) {
+		return "", WrongTypeError
+	}
+	items := kvs.kv[key].(listValue).items
+	last := items[len(items) - 1]
+	if len(items) == 1 {
+		delete(kvs.kv, key)
+		delete(kvs.ex, key)
+		kvs.changedLocked(KeyDeleteEvent, key)
+	} else {
+		kvs.kv[key] = listValue{
+			items: items[:len(items) - 1],
+		}
+		kvs.changedLocked(KeySetEvent, key)
+	}
+	return last, nil
+}
+
+func (kvs *KVStoreMemory) LRange(key string, start int64, stop int64) ([]string, error) {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	if !kvs.exists(key) {
+		return []string{}, nil
+	} else if !kvs.isList(key) {
+		return nil, WrongTypeError
+	}
+	items := kvs.kv[key].(listValue).items
+	length := int64(len(items))
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop {
+		return []string{}, nil
+	}
+	res := make([]string, stop - start + 1)
+	copy(res, items[start:stop + 1])
+	return res, nil
+}
+
+func (kvs *KVStoreMemory) Scan(pattern string, count int) Iterator {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	var keys []string
+	for k := range kvs.kv {
+		if kvs.exists(k) && MatchPattern(pattern, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return NewSliceIterator(keys, nil)
+}
+
+func (kvs *KVStoreMemory) HScan(key string, pattern string, count int) Iterator {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+	if !kvs.exists(key) {
+		return NewSliceIterator(nil, nil)
+	} else if !kvs.isHashMap(key) {
+		return NewErrorIterator(WrongTypeError)
+	}
+
+	var fields []string
+	for f := range kvs.kv[key].(hashMapValue).kvMap {
+		if MatchPattern(pattern, f) {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+
+	values := make([]string, len(fields))
+	for i, f := range fields {
+		values[i] = fmt.Sprint(kvs.kv[key].(hashMapValue).kvMap[f])
+	}
+	return NewSliceIterator(fields, values)
+}
+
 func (kvs *KVStoreMemory) SetGeneric(key string, value interface{}) {
 	kvs.rw.Lock()
 	defer kvs.rw.Unlock()
 	kvs.kv[key] = genericValue{
 		value: value,
 	}
+	kvs.changedLocked(KeySetEvent, key)
 }
 
 func (kvs *KVStoreMemory) GetGeneric(key string) (interface{}, error) {
@@ -245,14 +767,32 @@ func (kvs *KVStoreMemory) GetGeneric(key string) (interface{}, error) {
 
 func (kvs *KVStoreMemory) Close() {
 	close(kvs.closeChan)
+	kvs.bus.close()
+}
+
+type KVStoreMemoryOption func (kvs *KVStoreMemory)
+
+// WithMemoryClock sets the clock that expiries and the purge of expired keys
+// follow, it is an option as the purge starts with the store.
+func WithMemoryClock(clock Clock) KVStoreMemoryOption {
+	return func (kvs *KVStoreMemory) {
+		kvs.clock = clock
+	}
 }
 
-func NewKVStoreMemory() *KVStoreMemory {
+func NewKVStoreMemory(opts ...KVStoreMemoryOption) *KVStoreMemory {
 	kvs := &KVStoreMemory{
 		kv: make(map[string]interface{}),
 		ex: make(map[string]time.Time),
 		closeChan: make(chan struct{}),
+		bus: newMemoryEventBus(),
+		clock: SystemClock,
+		versions: make(map[string]uint64),
+		watching: make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(kvs)
 	}
-	go kvs.run()
+	go kvs.run(kvs.clock.NewTimer(memoryExpiryInterval))
 	return kvs
 }
\ No newline at end of file
@@ -0,0 +1,210 @@
+package turing
+
+import (
+	"time"
+)
+
+// memoryKeyVersion is what Watch remembers of a key. exists catches a key
+// that expired without being purged, its version does not change.
+type memoryKeyVersion struct {
+	version uint64
+	exists bool
+}
+
+func (kvs *KVStoreMemory) versionKeys(keys []string) map[string]memoryKeyVersion {
+	versions := make(map[string]memoryKeyVersion)
+	for _, key := range keys {
+		versions[key] = memoryKeyVersion{
+			version: kvs.versions[key],
+			exists: kvs.exists(key),
+		}
+	}
+	return versions
+}
+
+func (kvs *KVStoreMemory) keysUnchanged(watched map[string]memoryKeyVersion) bool {
+	for key, v := range watched {
+		if kvs.versions[key] != v.version || kvs.exists(key) != v.exists {
+			return false
+		}
+	}
+	return true
+}
+
+type memoryPipeline struct {
+	kvs *KVStoreMemory
+	watched map[string]memoryKeyVersion
+	ops []func () (interface{}, error)
+	results []*PipelineResult
+}
+
+func (mp *memoryPipeline) queue(op func () (interface{}, error)) *PipelineResult {
+	res := &PipelineResult{}
+	mp.ops = append(mp.ops, op)
+	mp.results = append(mp.results, res)
+	return res
+}
+
+func (mp *memoryPipeline) Set(key string, value interface{}) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return nil, mp.kvs.setLocked(key, value)
+	})
+}
+
+func (mp *memoryPipeline) Get(key string) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.getLocked(key)
+	})
+}
+
+func (mp *memoryPipeline) Delete(keys ...string) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.deleteLocked(keys...)
+	})
+}
+
+func (mp *memoryPipeline) Exists(keys ...string) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.existsLocked(keys...)
+	})
+}
+
+func (mp *memoryPipeline) HSet(key string, field string, value interface{}) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return nil, mp.kvs.hSetLocked(key, field, value)
+	})
+}
+
+func (mp *memoryPipeline) HSetMany(key string, kv map[string]interface{}) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return nil, mp.kvs.hSetManyLocked(key, kv)
+	})
+}
+
+func (mp *memoryPipeline) HGet(key string, field string) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.hGetLocked(key, field)
+	})
+}
+
+func (mp *memoryPipeline) HGetAll(key string) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.hGetAllLocked(key)
+	})
+}
+
+func (mp *memoryPipeline) HDelete(key string, fields ...string) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.hDeleteLocked(key, fields...)
+	})
+}
+
+func (mp *memoryPipeline) Expire(key string, expiry time.Duration) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return nil, mp.kvs.expireLocked(key, expiry)
+	})
+}
+
+func (mp *memoryPipeline) IncrBy(key string, value int64) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.incrByLocked(key, value)
+	})
+}
+
+func (mp *memoryPipeline) HIncrBy(key string, field string, value int64) *PipelineResult {
+	return mp.queue(func () (interface{}, error) {
+		return mp.kvs.hIncrByLocked(key, field, value)
+	})
+}
+
+func (mp *memoryPipeline) Exec() error {
+	mp.kvs.rw.Lock()
+	defer mp.kvs.rw.Unlock()
+
+	ops, results := mp.ops, mp.results
+	mp.ops, mp.results = nil, nil
+
+	if mp.watched != nil && !mp.kvs.keysUnchanged(mp.watched) {
+		for _, res := range results {
+			res.Err = TxFailedError
+		}
+		return TxFailedError
+	}
+
+	var firstErr error
+	for i, op := range ops {
+		results[i].Value, results[i].Err = op()
+		if results[i].Err != nil && results[i].Err != KeyNotExistsError && firstErr == nil {
+			firstErr = results[i].Err
+		}
+	}
+	return firstErr
+}
+
+type memoryTx struct {
+	kvs *KVStoreMemory
+	watched map[string]memoryKeyVersion
+}
+
+func (mt *memoryTx) Get(key string) (string, error) {
+	return mt.kvs.Get(key)
+}
+
+func (mt *memoryTx) Exists(keys ...string) (int, error) {
+	return mt.kvs.Exists(keys...)
+}
+
+func (mt *memoryTx) HGet(key string, field string) (string, error) {
+	return mt.kvs.HGet(key, field)
+}
+
+func (mt *memoryTx) HGetAll(key string) (map[string]string, error) {
+	return mt.kvs.HGetAll(key)
+}
+
+func (mt *memoryTx) TxPipeline() Pipeline {
+	return &memoryPipeline{
+		kvs: mt.kvs,
+		watched: mt.watched,
+	}
+}
+
+func (kvs *KVStoreMemory) Pipeline() Pipeline {
+	return &memoryPipeline{
+		kvs: kvs,
+	}
+}
+
+func (kvs *KVStoreMemory) TxPipeline() Pipeline {
+	return kvs.Pipeline()
+}
+
+// Watch aborts the transaction if any of keys is written to, even if it ends
+// up with the value it had, as redis does.
+func (kvs *KVStoreMemory) Watch(fn func (tx Tx) error, keys ...string) error {
+	kvs.rw.Lock()
+	watched := kvs.versionKeys(keys)
+	for _, key := range keys {
+		kvs.watching[key]++
+	}
+	kvs.rw.Unlock()
+
+	defer func () {
+		kvs.rw.Lock()
+		defer kvs.rw.Unlock()
+		for _, key := range keys {
+			kvs.watching[key]--
+			if kvs.watching[key] <= 0 {
+				delete(kvs.watching, key)
+				if _, ok := kvs.kv[key]; !ok {
+					delete(kvs.versions, key)
+				}
+			}
+		}
+	}()
+
+	return fn(&memoryTx{
+		kvs: kvs,
+		watched: watched,
+	})
+}
\ No newline at end of file
@@ -0,0 +1,528 @@
+package turing
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Snapshot layout (version 1), all integers big-endian:
+//
+//	magic "TKVS" | version byte
+//	entries: kind byte | key | expiry int64 (unix nano, 0 = none) | payload
+//	terminated by a zero kind byte
+//
+// Strings are written as a uvarint length followed by the bytes. Generic
+// values are gob encoded, so their concrete types must be registered
+// with gob.Register.
+const (
+	snapshotMagic = "TKVS"
+	snapshotVersion = 1
+	snapshotMaxValueSize = 512 * 1024 * 1024
+)
+
+const (
+	snapshotEnd byte = iota
+	snapshotString
+	snapshotHash
+	snapshotGeneric
+	snapshotSet
+	snapshotSortedSet
+	snapshotList
+)
+
+type snapshotGenericValue struct {
+	Value interface{}
+}
+
+type snapshotWriter struct {
+	w *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	n int64
+	err error
+}
+
+func (sw *snapshotWriter) write(p []byte) {
+	if sw.err != nil {
+		return
+	}
+	n, err := sw.w.Write(p)
+	sw.n += int64(n)
+	sw.err = err
+}
+
+func (sw *snapshotWriter) writeByte(b byte) {
+	sw.write([]byte{ b })
+}
+
+func (sw *snapshotWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(sw.buf[:], v)
+	sw.write(sw.buf[:n])
+}
+
+func (sw *snapshotWriter) writeUint64(v uint64) {
+	binary.BigEndian.PutUint64(sw.buf[:8], v)
+	sw.write(sw.buf[:8])
+}
+
+func (sw *snapshotWriter) writeString(s string) {
+	sw.writeUvarint(uint64(len(s)))
+	sw.write([]byte(s))
+}
+
+func (sw *snapshotWriter) writeStrings(items []string) {
+	sw.writeUvarint(uint64(len(items)))
+	for _, item := range items {
+		sw.writeString(item)
+	}
+}
+
+type snapshotReader struct {
+	r *bufio.Reader
+	buf [8]byte
+}
+
+func (sr *snapshotReader) readByte() (byte, error) {
+	b, err := sr.r.ReadByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+func (sr *snapshotReader) readUvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(sr.r)
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+func (sr *snapshotReader) readUint64() (uint64, error) {
+	if _, err := io.ReadFull(sr.r, sr.buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(sr.buf[:]), nil
+}
+
+func (sr *snapshotReader) readBytes() ([]byte, error) {
+	n, err := sr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > snapshotMaxValueSize {
+		return nil, InvalidSnapshotError
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(sr.r, b)
+	return b, err
+}
+
+func (sr *snapshotReader) readString() (string, error) {
+	b, err := sr.readBytes()
+	return string(b), err
+}
+
+func (sr *snapshotReader) readStrings() ([]string, error) {
+	n, err := sr.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	var items []string
+	for i := uint64(0); i < n; i++ {
+		item, err := sr.readString()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (kvs *KVStoreMemory) writeEntry(sw *snapshotWriter, key string) error {
+	var expiry int64
+	if t, ok := kvs.ex[key]; ok {
+		expiry = t.UnixNano()
+	}
+
+	writeHeader := func (kind byte) {
+		sw.writeByte(kind)
+		sw.writeString(key)
+		sw.writeUint64(uint64(expiry))
+	}
+
+	switch v := kvs.kv[key].(type) {
+	case stringValue:
+		writeHeader(snapshotString)
+		sw.writeString(fmt.Sprint(v.value))
+	case hashMapValue:
+		writeHeader(snapshotHash)
+		fields := sortedKeys(v.kvMap)
+		sw.writeUvarint(uint64(len(fields)))
+		for _, f := range fields {
+			sw.writeString(f)
+			sw.writeString(fmt.Sprint(v.kvMap[f]))
+		}
+	case genericValue:
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(snapshotGenericValue{ Value: v.value }); err != nil {
+			return err
+		}
+		writeHeader(snapshotGeneric)
+		sw.writeString(buf.String())
+	case setValue:
+		members := make([]string, 0, len(v.members))
+		for m := range v.members {
+			members = append(members, m)
+		}
+		sort.Strings(members)
+		writeHeader(snapshotSet)
+		sw.writeStrings(members)
+	case sortedSetValue:
+		members := make([]string, 0, len(v.scores))
+		for m := range v.scores {
+			members = append(members, m)
+		}
+		sort.Strings(members)
+		writeHeader(snapshotSortedSet)
+		sw.writeUvarint(uint64(len(members)))
+		for _, m := range members {
+			sw.writeString(m)
+			sw.writeUint64(math.Float64bits(v.scores[m]))
+		}
+	case listValue:
+		writeHeader(snapshotList)
+		sw.writeStrings(v.items)
+	}
+
+	return sw.err
+}
+
+// Snapshot writes every live key, its value and its expiry time to w.
+func (kvs *KVStoreMemory) Snapshot(w io.Writer) (int64, error) {
+	kvs.rw.RLock()
+	defer kvs.rw.RUnlock()
+
+	sw := &snapshotWriter{
+		w: bufio.NewWriter(w),
+	}
+	sw.write([]byte(snapshotMagic))
+	sw.writeByte(snapshotVersion)
+
+	keys := make([]string, 0, len(kvs.kv))
+	for k := range kvs.kv {
+		if kvs.exists(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if err := kvs.writeEntry(sw, k); err != nil {
+			return sw.n, err
+		}
+	}
+
+	sw.writeByte(snapshotEnd)
+	if sw.err == nil {
+		sw.err = sw.w.Flush()
+	}
+	return sw.n, sw.err
+}
+
+func readEntryValue(sr *snapshotReader, kind byte) (interface{}, error) {
+	switch kind {
+	case snapshotString:
+		s, err := sr.readString()
+		return stringValue{ value: s }, err
+	case snapshotHash:
+		n, err := sr.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		kvMap := make(map[string]interface{})
+		for i := uint64(0); i < n; i++ {
+			field, err := sr.readString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := sr.readString()
+			if err != nil {
+				return nil, err
+			}
+			kvMap[field] = value
+		}
+		return hashMapValue{ kvMap: kvMap }, nil
+	case snapshotGeneric:
+		b, err := sr.readBytes()
+		if err != nil {
+			return nil, err
+		}
+		var gv snapshotGenericValue
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&gv); err != nil {
+			return nil, err
+		}
+		return genericValue{ value: gv.Value }, nil
+	case snapshotSet:
+		members, err := sr.readStrings()
+		if err != nil {
+			return nil, err
+		}
+		set := make(map[string]struct{})
+		for _, m := range members {
+			set[m] = struct{}{}
+		}
+		return setValue{ members: set }, nil
+	case snapshotSortedSet:
+		n, err := sr.readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		scores := make(map[string]float64)
+		for i := uint64(0); i < n; i++ {
+			member, err := sr.readString()
+			if err != nil {
+				return nil, err
+			}
+			bits, err := sr.readUint64()
+			if err != nil {
+				return nil, err
+			}
+			scores[member] = math.Float64frombits(bits)
+		}
+		return sortedSetValue{ scores: scores }, nil
+	case snapshotList:
+		items, err := sr.readStrings()
+		return listValue{ items: items }, err
+	default:
+		return nil, InvalidSnapshotError
+	}
+}
+
+// readSnapshotEntries reads the entries that follow the snapshot header,
+// dropping those that expired before now.
+func readSnapshotEntries(sr *snapshotReader, now time.Time) (map[string]interface{}, map[string]time.Time, error) {
+	kv := make(map[string]interface{})
+	ex := make(map[string]time.Time)
+	for {
+		kind, err := sr.readByte()
+		if err != nil {
+			return nil, nil, err
+		}
+		if kind == snapshotEnd {
+			break
+		}
+
+		key, err := sr.readString()
+		if err != nil {
+			return nil, nil, err
+		}
+		expiry, err := sr.readUint64()
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := readEntryValue(sr, kind)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if expiry != 0 {
+			t := time.Unix(0, int64(expiry))
+			if !now.Before(t) {
+				continue
+			}
+			ex[key] = t
+		}
+		kv[key] = value
+	}
+	return kv, ex, nil
+}
+
+// Restore replaces the contents of the store with the snapshot read from r.
+// Keys that have expired since the snapshot was taken are dropped. The store
+// is left untouched if the snapshot cannot be read. Watchers see the keys
+// that were dropped as deleted and every restored key as set.
+func (kvs *KVStoreMemory) Restore(r io.Reader) error {
+	sr := &snapshotReader{
+		r: bufio.NewReader(r),
+	}
+
+	header := make([]byte, len(snapshotMagic) + 1)
+	if _, err := io.ReadFull(sr.r, header); err != nil {
+		return InvalidSnapshotError
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic || header[len(snapshotMagic)] != snapshotVersion {
+		return InvalidSnapshotError
+	}
+
+	kv, ex, err := readSnapshotEntries(sr, kvs.clock.Now())
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return InvalidSnapshotError
+	} else if err != nil {
+		return err
+	}
+
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	previous := kvs.kv
+	kvs.kv = kv
+	kvs.ex = ex
+	for key := range previous {
+		if _, ok := kv[key]; !ok {
+			kvs.changedLocked(KeyDeleteEvent, key)
+		}
+	}
+	for key := range kv {
+		kvs.changedLocked(KeySetEvent, key)
+	}
+	return nil
+}
+
+// SnapshotFile writes a snapshot to path. It refuses to overwrite a snapshot
+// that failed to restore, so that its data is not replaced by an empty store.
+func (kvs *KVStoreMemory) SnapshotFile(path string) error {
+	kvs.rw.RLock()
+	unrestored := kvs.unrestored
+	kvs.rw.RUnlock()
+	if unrestored == path {
+		return InvalidSnapshotError
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path) + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := kvs.Snapshot(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (kvs *KVStoreMemory) RestoreFile(path string) error {
+	f, err := os.Open(path)
+	if err == nil {
+		err = kvs.Restore(f)
+		f.Close()
+	}
+
+	kvs.rw.Lock()
+	defer kvs.rw.Unlock()
+	if err != nil && !os.IsNotExist(err) {
+		kvs.unrestored = path
+	} else if kvs.unrestored == path {
+		kvs.unrestored = ""
+	}
+	return err
+}
+
+// restoreSnapshotFile is RestoreFile for a snapshot that may not have been
+// written yet, a missing file leaves the store empty.
+func (kvs *KVStoreMemory) restoreSnapshotFile(path string) error {
+	if err := kvs.RestoreFile(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// NewKVStoreMemoryFromSnapshot creates a store holding the snapshot at path.
+// A missing file gives an empty store, a snapshot that can't be read is an
+// error.
+func NewKVStoreMemoryFromSnapshot(path string, opts ...KVStoreMemoryOption) (*KVStoreMemory, error) {
+	kvs := NewKVStoreMemory(opts...)
+	if err := kvs.restoreSnapshotFile(path); err != nil {
+		kvs.Close()
+		return nil, err
+	}
+	return kvs, nil
+}
+
+type MemorySnapshotter struct {
+	store *KVStoreMemory
+	path string
+	interval time.Duration
+	clock Clock
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func (ms *MemorySnapshotter) SetClock(clock Clock) {
+	ms.clock = clock
+}
+
+func (ms *MemorySnapshotter) snapshot() error {
+	err := ms.store.SnapshotFile(ms.path)
+	if err != nil {
+		Log.WithError(err).WithFields(LogFields{
+			"path": ms.path,
+		}).Error("Could not write memory store snapshot")
+	}
+	return err
+}
+
+func (ms *MemorySnapshotter) Close() {
+	ms.closeOnce.Do(func () {
+		close(ms.closeChan)
+	})
+}
+
+func (ms *MemorySnapshotter) Run() error {
+	if ms.interval <= 0 {
+		<- ms.closeChan
+		return ms.snapshot()
+	}
+
+	timer := ms.clock.NewTimer(ms.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <- ms.closeChan:
+			return ms.snapshot()
+		case <- timer.C():
+			ms.snapshot()
+			timer.Reset(ms.interval)
+		}
+	}
+}
+
+// NewMemorySnapshotter writes a snapshot of store every interval and once more
+// when closed. An interval of zero or less only snapshots on close.
+func NewMemorySnapshotter(store *KVStoreMemory, path string, interval time.Duration) *MemorySnapshotter {
+	return &MemorySnapshotter{
+		store: store,
+		path: path,
+		interval: interval,
+		clock: SystemClock,
+		closeChan: make(chan struct{}),
+	}
+}
\ No newline at end of file
@@ -0,0 +1,194 @@
+package turing
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+type snapshotPoint struct {
+	X int
+	Y int
+}
+
+// stoppedClock is a clock whose time never moves, its timers are real.
+type stoppedClock struct {
+	now time.Time
+}
+
+func (sc stoppedClock) Now() time.Time {
+	return sc.now
+}
+
+func (sc stoppedClock) NewTimer(d time.Duration) Timer {
+	return SystemClock.NewTimer(d)
+}
+
+func init() {
+	gob.Register(snapshotPoint{})
+}
+
+func TestKVStoreMemorySnapshotRestore(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	store.Set("str", "value")
+	store.HSetMany("hash", map[string]interface{}{ "a": "1", "b": 2 })
+	store.SetGeneric("generic", snapshotPoint{ X: 1, Y: 2 })
+	store.SAdd("set", "x", "y")
+	store.ZAdd("zset", ZMember{ Member: "m", Score: 1.5 })
+	store.LPush("list", "1", "2", "3")
+	store.Set("ttl", "v")
+	store.Expire("ttl", time.Hour)
+	store.Set("expired", "v")
+	store.Expire("expired", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	var buf bytes.Buffer
+	n, err := store.Snapshot(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	restored := NewKVStoreMemory()
+	defer restored.Close()
+	restored.Set("stale", "v")
+	assert.Nil(t, restored.Restore(&buf))
+
+	val, err := restored.Get("str")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+
+	hash, _ := restored.HGetAll("hash")
+	assert.Equal(t, map[string]string{ "a": "1", "b": "2" }, hash)
+
+	generic, err := restored.GetGeneric("generic")
+	assert.Nil(t, err)
+	assert.Equal(t, snapshotPoint{ X: 1, Y: 2 }, generic)
+
+	members, _ := restored.SMembers("set")
+	assert.ElementsMatch(t, []string{ "x", "y" }, members)
+
+	zmembers, _ := restored.ZRangeByScore("zset", 0, 10)
+	assert.Equal(t, []ZMember{ { Member: "m", Score: 1.5 } }, zmembers)
+
+	items, _ := restored.LRange("list", 0, -1)
+	assert.Equal(t, []string{ "3", "2", "1" }, items)
+
+	assert.Equal(t, store.ex["ttl"].UnixNano(), restored.ex["ttl"].UnixNano())
+
+	n2, _ := restored.Exists("expired", "stale")
+	assert.Equal(t, 0, n2)
+}
+
+func TestKVStoreMemoryRestoreInvalid(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+	store.Set("a", "1")
+
+	assert.Equal(t, InvalidSnapshotError, store.Restore(bytes.NewReader([]byte("nope"))))
+	assert.Equal(t, InvalidSnapshotError, store.Restore(bytes.NewReader([]byte("TKVS\x09"))))
+
+	var buf bytes.Buffer
+	store.Snapshot(&buf)
+	assert.Equal(t, InvalidSnapshotError, store.Restore(bytes.NewReader(buf.Bytes()[:buf.Len() - 2])))
+	assert.Equal(t, InvalidSnapshotError, store.Restore(bytes.NewReader(buf.Bytes()[:buf.Len() - 1])))
+
+	val, _ := store.Get("a")
+	assert.Equal(t, "1", val)
+}
+
+func TestKVStoreMemorySnapshotFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.snap")
+
+	empty, err := NewKVStoreMemoryFromSnapshot(path)
+	assert.Nil(t, err)
+	defer empty.Close()
+	n, _ := empty.Exists("a")
+	assert.Equal(t, 0, n)
+
+	store := NewKVStoreMemory()
+	defer store.Close()
+	store.Set("a", "1")
+
+	snapshotter := NewMemorySnapshotter(store, path, time.Hour)
+	done := make(chan error)
+	go func () {
+		done <- snapshotter.Run()
+	}()
+	snapshotter.Close()
+	assert.Nil(t, <- done)
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(t, files, 1)
+
+	loaded, err := NewKVStoreMemoryFromSnapshot(path)
+	assert.Nil(t, err)
+	defer loaded.Close()
+	val, err := loaded.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+}
+
+func TestKVStoreMemorySnapshotFileCorrupt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.snap")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("garbage"), 0600))
+
+	_, err = NewKVStoreMemoryFromSnapshot(path)
+	assert.Equal(t, InvalidSnapshotError, err)
+
+	store := NewKVStoreMemory()
+	defer store.Close()
+	assert.Equal(t, InvalidSnapshotError, store.RestoreFile(path))
+	snapshotter := NewMemorySnapshotter(store, path, 0)
+	done := make(chan error)
+	go func () {
+		done <- snapshotter.Run()
+	}()
+	snapshotter.Close()
+	assert.Equal(t, InvalidSnapshotError, <- done)
+
+	data, _ := ioutil.ReadFile(path)
+	assert.Equal(t, "garbage", string(data))
+
+	missing, err := NewKVStoreMemoryFromSnapshot(filepath.Join(dir, "missing.snap"))
+	assert.Nil(t, err)
+	missing.Close()
+}
+
+func TestKVStoreMemorySnapshotFileClock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.snap")
+
+	start := time.Now()
+	store := NewKVStoreMemory(WithMemoryClock(stoppedClock{ start }))
+	defer store.Close()
+	store.Set("a", "1")
+	store.Expire("a", time.Minute)
+	assert.Nil(t, store.SnapshotFile(path))
+
+	// Expiry is judged by the store's clock.
+	expired, err := NewKVStoreMemoryFromSnapshot(path, WithMemoryClock(stoppedClock{ start.Add(time.Hour) }))
+	assert.Nil(t, err)
+	defer expired.Close()
+	n, _ := expired.Exists("a")
+	assert.Equal(t, 0, n)
+
+	loaded, err := NewKVStoreMemoryFromSnapshot(path, WithMemoryClock(stoppedClock{ start.Add(time.Second) }))
+	assert.Nil(t, err)
+	defer loaded.Close()
+	n, _ = loaded.Exists("a")
+	assert.Equal(t, 1, n)
+}
\ No newline at end of file
@@ -0,0 +1,30 @@
+package turing_test
+
+import (
+	"testing"
+	"time"
+	"github.com/areller/turing"
+	"github.com/areller/turing/kvtest"
+)
+
+func TestKVStoreMemory(t *testing.T) {
+	kvtest.Run(t, func () turing.KVStore {
+		return turing.NewKVStoreMemory()
+	}, nil)
+}
+
+func TestInstrumentedKVStoreConformance(t *testing.T) {
+	kvtest.Run(t, func () turing.KVStore {
+		store := turing.NewKVStoreMemory()
+		t.Cleanup(store.Close)
+		return turing.InstrumentKVStore(store)
+	}, nil)
+}
+
+func TestCachedKVStoreConformance(t *testing.T) {
+	kvtest.Run(t, func () turing.KVStore {
+		store := turing.NewKVStoreMemory()
+		t.Cleanup(store.Close)
+		return turing.NewCachedKVStore(store, 100, time.Minute)
+	}, nil)
+}
\ No newline at end of file
@@ -0,0 +1,96 @@
+package turing
+
+import (
+	"sync"
+)
+
+const watchBufferSize = 1024
+
+type memoryWatcher struct {
+	bus *memoryEventBus
+	pattern string
+	events chan KeyEvent
+	closeOnce sync.Once
+}
+
+func (mw *memoryWatcher) Events() <-chan KeyEvent {
+	return mw.events
+}
+
+func (mw *memoryWatcher) Close() {
+	mw.closeOnce.Do(func () {
+		mw.bus.remove(mw)
+	})
+}
+
+type memoryEventBus struct {
+	watchers map[*memoryWatcher]struct{}
+	closed bool
+	mutex sync.Mutex
+}
+
+func (meb *memoryEventBus) add(pattern string) (*memoryWatcher, error) {
+	meb.mutex.Lock()
+	defer meb.mutex.Unlock()
+	if meb.closed {
+		return nil, NotRunningError
+	}
+
+	watcher := &memoryWatcher{
+		bus: meb,
+		pattern: pattern,
+		events: make(chan KeyEvent, watchBufferSize),
+	}
+	meb.watchers[watcher] = struct{}{}
+	return watcher, nil
+}
+
+func (meb *memoryEventBus) remove(watcher *memoryWatcher) {
+	meb.mutex.Lock()
+	defer meb.mutex.Unlock()
+	if _, ok := meb.watchers[watcher]; ok {
+		delete(meb.watchers, watcher)
+		close(watcher.events)
+	}
+}
+
+func (meb *memoryEventBus) publish(eventType KeyEventType, key string) {
+	meb.mutex.Lock()
+	defer meb.mutex.Unlock()
+	for watcher := range meb.watchers {
+		if !MatchPattern(watcher.pattern, key) {
+			continue
+		}
+
+		select {
+		case watcher.events <- KeyEvent{ Type: eventType, Key: key }:
+		default:
+			Log.WithFields(LogFields{
+				"key": key,
+				"pattern": watcher.pattern,
+			}).Warn("Watcher is too slow, closing it")
+			delete(meb.watchers, watcher)
+			close(watcher.events)
+		}
+	}
+}
+
+func (meb *memoryEventBus) close() {
+	meb.mutex.Lock()
+	defer meb.mutex.Unlock()
+	meb.closed = true
+	for watcher := range meb.watchers {
+		delete(meb.watchers, watcher)
+		close(watcher.events)
+	}
+}
+
+func newMemoryEventBus() *memoryEventBus {
+	return &memoryEventBus{
+		watchers: make(map[*memoryWatcher]struct{}),
+	}
+}
+
+func (kvs *KVStoreMemory) WatchPattern(pattern string) (Subscription, error) {
+	return kvs.bus.add(pattern)
+}
\ No newline at end of file
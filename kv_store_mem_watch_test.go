@@ -0,0 +1,96 @@
+package turing
+
+import (
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+func nextKeyEvent(t *testing.T, sub Subscription) KeyEvent {
+	select {
+	case event := <- sub.Events():
+		return event
+	case <- time.After(time.Second):
+		t.Fatal("Timed out waiting for key event")
+		return KeyEvent{}
+	}
+}
+
+func TestKVStoreMemoryWatchPattern(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	sub, err := store.WatchPattern("user:*")
+	assert.Nil(t, err)
+	defer sub.Close()
+
+	store.Set("order:1", "v")
+	store.Set("user:1", "v")
+	store.HSet("user:2", "f", "v")
+	store.HDelete("user:2", "f")
+	store.Delete("user:1")
+	store.Delete("user:missing")
+
+	assert.Equal(t, KeyEvent{ Type: KeySetEvent, Key: "user:1" }, nextKeyEvent(t, sub))
+	assert.Equal(t, KeyEvent{ Type: KeySetEvent, Key: "user:2" }, nextKeyEvent(t, sub))
+	assert.Equal(t, KeyEvent{ Type: KeyDeleteEvent, Key: "user:2" }, nextKeyEvent(t, sub))
+	assert.Equal(t, KeyEvent{ Type: KeyDeleteEvent, Key: "user:1" }, nextKeyEvent(t, sub))
+
+	store.Set("user:3", "v")
+	store.Expire("user:3", time.Millisecond)
+	assert.Equal(t, KeyEvent{ Type: KeySetEvent, Key: "user:3" }, nextKeyEvent(t, sub))
+	time.Sleep(5 * time.Millisecond)
+	store.deleteExpired()
+	assert.Equal(t, KeyEvent{ Type: KeyExpireEvent, Key: "user:3" }, nextKeyEvent(t, sub))
+
+	select {
+	case event := <- sub.Events():
+		t.Fatalf("Unexpected event %v", event)
+	default:
+	}
+}
+
+func TestKVStoreMemoryWatchClose(t *testing.T) {
+	store := NewKVStoreMemory()
+	sub, err := store.WatchPattern("*")
+	assert.Nil(t, err)
+
+	sub.Close()
+	_, ok := <- sub.Events()
+	assert.False(t, ok)
+	store.Set("a", "v")
+
+	other, err := store.WatchPattern("*")
+	assert.Nil(t, err)
+	store.Close()
+	_, ok = <- other.Events()
+	assert.False(t, ok)
+	other.Close()
+
+	_, err = store.WatchPattern("*")
+	assert.Equal(t, NotRunningError, err)
+}
+
+func TestKVStoreMemoryWatchSlowSubscriber(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+	slow, err := store.WatchPattern("*")
+	assert.Nil(t, err)
+	defer slow.Close()
+
+	for i := 0; i <= watchBufferSize; i++ {
+		store.Set("a", i)
+	}
+
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	assert.Equal(t, watchBufferSize, received)
+
+	sub, err := store.WatchPattern("*")
+	assert.Nil(t, err)
+	defer sub.Close()
+	store.Set("b", "v")
+	assert.Equal(t, KeyEvent{ Type: KeySetEvent, Key: "b" }, nextKeyEvent(t, sub))
+}
\ No newline at end of file
@@ -0,0 +1,360 @@
+package turing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (cs CircuitState) String() string {
+	switch cs {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+func transientError(err error) bool {
+	return err == ConnectionDroppedError || err == NotConnectedError
+}
+
+// circuitBreaker opens after threshold consecutive failed operations and
+// lets a single trial operation through once openTimeout has passed.
+type circuitBreaker struct {
+	threshold int
+	openTimeout time.Duration
+	onStateChange func (from CircuitState, to CircuitState)
+	state CircuitState
+	failures int
+	openedAt time.Time
+	trial bool
+	clock Clock
+	mutex sync.Mutex
+}
+
+// setStateLocked returns the state change notification, which must be
+// called after unlocking as the callback may read the state.
+func (cb *circuitBreaker) setStateLocked(state CircuitState) func () {
+	if cb.state == state {
+		return func () {}
+	}
+
+	from := cb.state
+	cb.state = state
+	Log.WithFields(LogFields{
+		"from": from.String(),
+		"to": state.String(),
+	}).Warn("Key-value store circuit breaker changed state")
+
+	onStateChange := cb.onStateChange
+	return func () {
+		if onStateChange != nil {
+			onStateChange(from, state)
+		}
+	}
+}
+
+func (cb *circuitBreaker) allow() bool {
+	notify := func () {}
+	defer func () {
+		notify()
+	}()
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	switch cb.state {
+	case CircuitOpen:
+		if cb.clock.Now().Sub(cb.openedAt) < cb.openTimeout {
+			return false
+		}
+		notify = cb.setStateLocked(CircuitHalfOpen)
+		cb.trial = true
+		return true
+	case CircuitHalfOpen:
+		if cb.trial {
+			return false
+		}
+		cb.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) record(failed bool) {
+	notify := func () {}
+	defer func () {
+		notify()
+	}()
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.trial = false
+	if !failed {
+		cb.failures = 0
+		notify = cb.setStateLocked(CircuitClosed)
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.threshold {
+		cb.openedAt = cb.clock.Now()
+		notify = cb.setStateLocked(CircuitOpen)
+	}
+}
+
+func (cb *circuitBreaker) current() CircuitState {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state == CircuitOpen && cb.clock.Now().Sub(cb.openedAt) >= cb.openTimeout {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+type ResilientKVStore struct {
+	store KVStore
+	ctx context.Context
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	breaker *circuitBreaker
+}
+
+func (rks *ResilientKVStore) SetRetries(maxRetries int, minBackoff time.Duration, maxBackoff time.Duration) {
+	rks.maxRetries = maxRetries
+	rks.minBackoff = minBackoff
+	rks.maxBackoff = maxBackoff
+}
+
+func (rks *ResilientKVStore) SetCircuitBreaker(threshold int, openTimeout time.Duration) {
+	rks.breaker.mutex.Lock()
+	defer rks.breaker.mutex.Unlock()
+	rks.breaker.threshold = threshold
+	rks.breaker.openTimeout = openTimeout
+}
+
+func (rks *ResilientKVStore) SetOnStateChange(onStateChange func (from CircuitState, to CircuitState)) {
+	rks.breaker.mutex.Lock()
+	defer rks.breaker.mutex.Unlock()
+	rks.breaker.onStateChange = onStateChange
+}
+
+// SetClock sets the clock used for backoff and circuit breaking, it is shared
+// with the views returned by WithContext.
+func (rks *ResilientKVStore) SetClock(clock Clock) {
+	rks.breaker.mutex.Lock()
+	defer rks.breaker.mutex.Unlock()
+	rks.breaker.clock = clock
+}
+
+func (rks *ResilientKVStore) State() CircuitState {
+	return rks.breaker.current()
+}
+
+func (rks *ResilientKVStore) backoff(attempt int) time.Duration {
+	delay := rks.minBackoff
+	for i := 0; i < attempt && delay < rks.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > rks.maxBackoff {
+		delay = rks.maxBackoff
+	}
+	return delay
+}
+
+func (rks *ResilientKVStore) wait(delay time.Duration) bool {
+	rks.breaker.mutex.Lock()
+	clock := rks.breaker.clock
+	rks.breaker.mutex.Unlock()
+
+	timer := clock.NewTimer(delay)
+	select {
+	case <- rks.ctx.Done():
+		timer.Stop()
+		return false
+	case <- timer.C():
+		return true
+	}
+}
+
+func (rks *ResilientKVStore) retry(what func () error, retryable func (err error) bool) error {
+	if !rks.breaker.allow() {
+		return CircuitOpenError
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = what()
+		if !retryable(err) || attempt >= rks.maxRetries || !rks.wait(rks.backoff(attempt)) {
+			break
+		}
+	}
+
+	rks.breaker.record(transientError(err))
+	return err
+}
+
+func (rks *ResilientKVStore) do(what func () error) error {
+	return rks.retry(what, transientError)
+}
+
+// doOnce is for operations whose result would be wrong if an attempt that
+// was cut off had been applied, such as the counts returned by Delete. They
+// are only retried when they were never sent.
+func (rks *ResilientKVStore) doOnce(what func () error) error {
+	return rks.retry(what, func (err error) bool {
+		return err == NotConnectedError
+	})
+}
+
+func (rks *ResilientKVStore) Ping() error {
+	if rks.State() == CircuitOpen {
+		return CircuitOpenError
+	}
+
+	if pinger, ok := rks.store.(Pinger); ok {
+		return rks.do(pinger.Ping)
+	}
+	return nil
+}
+
+func (rks *ResilientKVStore) HealthCheck() HealthCheck {
+	return func () error {
+		if rks.State() == CircuitOpen {
+			return CircuitOpenError
+		}
+		return nil
+	}
+}
+
+func (rks *ResilientKVStore) WithContext(ctx context.Context) KVStore {
+	return &ResilientKVStore{
+		store: KVStoreWithContext(ctx, rks.store),
+		ctx: ctx,
+		maxRetries: rks.maxRetries,
+		minBackoff: rks.minBackoff,
+		maxBackoff: rks.maxBackoff,
+		breaker: rks.breaker,
+	}
+}
+
+func (rks *ResilientKVStore) Set(key string, value interface{}) error {
+	return rks.do(func () error {
+		return rks.store.Set(key, value)
+	})
+}
+
+func (rks *ResilientKVStore) Get(key string) (string, error) {
+	var res string
+	err := rks.do(func () error {
+		var err error
+		res, err = rks.store.Get(key)
+		return err
+	})
+	return res, err
+}
+
+func (rks *ResilientKVStore) Delete(keys ...string) (int, error) {
+	var res int
+	err := rks.doOnce(func () error {
+		var err error
+		res, err = rks.store.Delete(keys...)
+		return err
+	})
+	return res, err
+}
+
+func (rks *ResilientKVStore) Exists(keys ...string) (int, error) {
+	var res int
+	err := rks.do(func () error {
+		var err error
+		res, err = rks.store.Exists(keys...)
+		return err
+	})
+	return res, err
+}
+
+func (rks *ResilientKVStore) HSet(key string, field string, value interface{}) error {
+	return rks.do(func () error {
+		return rks.store.HSet(key, field, value)
+	})
+}
+
+func (rks *ResilientKVStore) HSetMany(key string, kv map[string]interface{}) error {
+	return rks.do(func () error {
+		return rks.store.HSetMany(key, kv)
+	})
+}
+
+func (rks *ResilientKVStore) HGet(key string, field string) (string, error) {
+	var res string
+	err := rks.do(func () error {
+		var err error
+		res, err = rks.store.HGet(key, field)
+		return err
+	})
+	return res, err
+}
+
+func (rks *ResilientKVStore) HGetAll(key string) (map[string]string, error) {
+	var res map[string]string
+	err := rks.do(func () error {
+		var err error
+		res, err = rks.store.HGetAll(key)
+		return err
+	})
+	return res, err
+}
+
+func (rks *ResilientKVStore) HDelete(key string, fields ...string) (int, error) {
+	var res int
+	err := rks.doOnce(func () error {
+		var err error
+		res, err = rks.store.HDelete(key, fields...)
+		return err
+	})
+	return res, err
+}
+
+func (rks *ResilientKVStore) Expire(key string, expiry time.Duration) error {
+	return rks.do(func () error {
+		return rks.store.Expire(key, expiry)
+	})
+}
+
+func NewResilientKVStore(store KVStore) *ResilientKVStore {
+	return &ResilientKVStore{
+		store: store,
+		ctx: context.Background(),
+		maxRetries: 3,
+		minBackoff: 50 * time.Millisecond,
+		maxBackoff: 2 * time.Second,
+		breaker: &circuitBreaker{
+			threshold: 5,
+			openTimeout: 10 * time.Second,
+			clock: SystemClock,
+		},
+	}
+}
+
+func asResilientKVStore(store KVStore) *ResilientKVStore {
+	if resilient, ok := store.(*ResilientKVStore); ok {
+		return resilient
+	}
+	return NewResilientKVStore(store)
+}
\ No newline at end of file
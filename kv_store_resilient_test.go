@@ -0,0 +1,183 @@
+package turing
+
+import (
+	"context"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyKVStore struct {
+	*KVStoreMemory
+	failures int
+	calls int
+}
+
+func (fks *flakyKVStore) HSet(key string, field string, value interface{}) error {
+	fks.calls++
+	if fks.failures > 0 {
+		fks.failures--
+		return ConnectionDroppedError
+	}
+	return fks.KVStoreMemory.HSet(key, field, value)
+}
+
+func (fks *flakyKVStore) HGet(key string, field string) (string, error) {
+	fks.calls++
+	if fks.failures > 0 {
+		fks.failures--
+		return "", ConnectionDroppedError
+	}
+	return fks.KVStoreMemory.HGet(key, field)
+}
+
+func (fks *flakyKVStore) Delete(keys ...string) (int, error) {
+	fks.calls++
+	if fks.failures > 0 {
+		fks.failures--
+		return 0, ConnectionDroppedError
+	}
+	return fks.KVStoreMemory.Delete(keys...)
+}
+
+func newFlakyKVStore(failures int) *flakyKVStore {
+	return &flakyKVStore{
+		KVStoreMemory: NewKVStoreMemory(),
+		failures: failures,
+	}
+}
+
+func TestResilientKVStoreRetries(t *testing.T) {
+	flaky := newFlakyKVStore(2)
+	defer flaky.Close()
+	store := NewResilientKVStore(flaky)
+	store.SetRetries(3, time.Millisecond, 5 * time.Millisecond)
+
+	assert.Nil(t, store.HSet("h", "f", "1"))
+	assert.Equal(t, 3, flaky.calls)
+
+	flaky.calls = 0
+	_, err := store.HGet("h", "missing")
+	assert.Equal(t, KeyNotExistsError, err)
+	assert.Equal(t, 1, flaky.calls)
+
+	flaky.calls = 0
+	flaky.failures = 10
+	assert.Equal(t, ConnectionDroppedError, store.HSet("h", "f", "1"))
+	assert.Equal(t, 4, flaky.calls)
+	assert.Equal(t, CircuitClosed, store.State())
+}
+
+func TestResilientKVStoreDoesNotRetryDroppedDelete(t *testing.T) {
+	flaky := newFlakyKVStore(1)
+	defer flaky.Close()
+	store := NewResilientKVStore(flaky)
+	store.SetRetries(3, time.Millisecond, time.Millisecond)
+
+	_, err := store.Delete("a")
+	assert.Equal(t, ConnectionDroppedError, err)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestResilientKVStoreCircuitBreaker(t *testing.T) {
+	flaky := newFlakyKVStore(100)
+	defer flaky.Close()
+	store := NewResilientKVStore(flaky)
+	store.SetRetries(0, time.Millisecond, time.Millisecond)
+	store.SetCircuitBreaker(2, 20 * time.Millisecond)
+
+	var transitions []CircuitState
+	store.SetOnStateChange(func (from CircuitState, to CircuitState) {
+		assert.Equal(t, to, store.State())
+		transitions = append(transitions, to)
+	})
+
+	store.HSet("h", "f", "1")
+	assert.Equal(t, CircuitClosed, store.State())
+	store.HSet("h", "f", "1")
+	assert.Equal(t, CircuitOpen, store.State())
+	assert.Equal(t, CircuitOpenError, store.HealthCheck()())
+	assert.Equal(t, CircuitOpenError, store.Ping())
+
+	flaky.calls = 0
+	assert.Equal(t, CircuitOpenError, store.HSet("h", "f", "1"))
+	assert.Equal(t, 0, flaky.calls)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, CircuitHalfOpen, store.State())
+	assert.Equal(t, ConnectionDroppedError, store.HSet("h", "f", "1"))
+	assert.Equal(t, CircuitOpen, store.State())
+
+	flaky.failures = 0
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(t, store.HSet("h", "f", "1"))
+	assert.Equal(t, CircuitClosed, store.State())
+	assert.Nil(t, store.HealthCheck()())
+
+	assert.Equal(t, []CircuitState{ CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed }, transitions)
+}
+
+func TestResilientKVStoreContext(t *testing.T) {
+	flaky := newFlakyKVStore(100)
+	defer flaky.Close()
+	store := NewResilientKVStore(flaky)
+	store.SetRetries(10, time.Hour, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, store.WithContext(ctx).HSet("h", "f", "1"))
+}
+
+func TestKVStoreCommitDoesNotPanic(t *testing.T) {
+	flaky := newFlakyKVStore(100)
+	defer flaky.Close()
+	store := NewResilientKVStore(flaky)
+	store.SetRetries(1, time.Millisecond, time.Millisecond)
+
+	sp := &SimpleProcessor{
+		closeChan: make(chan struct{}),
+		clock: SystemClock,
+	}
+	sp.SetKVStoreBehavior("group", store)
+
+	p := NewPartition("topic", 1)
+	assert.NotPanics(t, func () {
+		sp.commitBehavior(p, MessageEvent{ Offset: 10 })
+	})
+
+	flaky.failures = 0
+	sp.commitBehavior(p, MessageEvent{ Offset: 10 })
+	assert.Equal(t, int64(11), sp.offsetPickBehavior(p))
+}
+
+func TestKVStoreOffsetPickRetries(t *testing.T) {
+	flaky := newFlakyKVStore(0)
+	defer flaky.Close()
+	store := NewResilientKVStore(flaky)
+	store.SetRetries(1, time.Millisecond, time.Millisecond)
+	store.SetCircuitBreaker(2, time.Millisecond)
+
+	sp := &SimpleProcessor{
+		closeChan: make(chan struct{}),
+		clock: SystemClock,
+	}
+	sp.SetKVStoreBehavior("group", store)
+
+	p := NewPartition("topic", 1)
+	sp.commitBehavior(p, MessageEvent{ Offset: 10 })
+
+	// The store fails long enough to open the circuit, the pick waits it out
+	// rather than falling back to another offset.
+	flaky.failures = 7
+	assert.Equal(t, int64(11), sp.offsetPickBehavior(p))
+	assert.Equal(t, 0, flaky.failures)
+
+	flaky.failures = 100
+	picked := make(chan int64)
+	go func () {
+		picked <- sp.offsetPickBehavior(p)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(sp.closeChan)
+	assert.Equal(t, int64(OffsetNone), <- picked)
+}
\ No newline at end of file
@@ -0,0 +1,19 @@
+package turing
+
+import (
+	"context"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKVStoreWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := KVStoreWithContext(ctx, stubKVStore{})
+
+	assert.Nil(t, store.Set("a", "b"))
+	cancel()
+	assert.Equal(t, context.Canceled, store.Set("a", "b"))
+
+	_, err := store.Get("a")
+	assert.Equal(t, context.Canceled, err)
+}
\ No newline at end of file
@@ -0,0 +1,69 @@
+package turing
+
+type TypedKVStore struct {
+	store KVStore
+	codec ValueCodec
+}
+
+func (tks *TypedKVStore) Store() KVStore {
+	return tks.store
+}
+
+func (tks *TypedKVStore) Set(key string, value interface{}) error {
+	data, err := tks.codec.EncodeValue(value)
+	if err != nil {
+		return err
+	}
+	return tks.store.Set(key, data)
+}
+
+func (tks *TypedKVStore) GetInto(key string, dest interface{}) error {
+	return GetInto(tks.store, tks.codec, key, dest)
+}
+
+func (tks *TypedKVStore) HSet(key string, field string, value interface{}) error {
+	data, err := tks.codec.EncodeValue(value)
+	if err != nil {
+		return err
+	}
+	return tks.store.HSet(key, field, data)
+}
+
+func (tks *TypedKVStore) HSetMany(key string, kv map[string]interface{}) error {
+	encoded := make(map[string]interface{}, len(kv))
+	for field, value := range kv {
+		data, err := tks.codec.EncodeValue(value)
+		if err != nil {
+			return err
+		}
+		encoded[field] = data
+	}
+	return tks.store.HSetMany(key, encoded)
+}
+
+func (tks *TypedKVStore) HGetInto(key string, field string, dest interface{}) error {
+	return HGetInto(tks.store, tks.codec, key, field, dest)
+}
+
+func GetInto(store KVStore, codec ValueCodec, key string, dest interface{}) error {
+	value, err := store.Get(key)
+	if err != nil {
+		return err
+	}
+	return codec.DecodeValue([]byte(value), dest)
+}
+
+func HGetInto(store KVStore, codec ValueCodec, key string, field string, dest interface{}) error {
+	value, err := store.HGet(key, field)
+	if err != nil {
+		return err
+	}
+	return codec.DecodeValue([]byte(value), dest)
+}
+
+func NewTypedKVStore(store KVStore, codec ValueCodec) *TypedKVStore {
+	return &TypedKVStore{
+		store: store,
+		codec: codec,
+	}
+}
\ No newline at end of file
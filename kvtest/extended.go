@@ -0,0 +1,211 @@
+package kvtest
+
+import (
+	"sort"
+	"testing"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+type ExtendedFactory func () turing.ExtendedKVStore
+
+func RunExtendedSuite(t *testing.T, factory ExtendedFactory) {
+	run := func (name string, test func (t *testing.T, store turing.ExtendedKVStore)) {
+		t.Run(name, func (t *testing.T) {
+			store := factory()
+			closeOnCleanup(t, store)
+			test(t, store)
+		})
+	}
+
+	run("Counters", testCounters)
+	run("Sets", testSets)
+	run("SortedSets", testSortedSets)
+	run("Lists", testLists)
+	run("WrongType", testWrongType)
+}
+
+func testCounters(t *testing.T, store turing.ExtendedKVStore) {
+	n, err := store.Incr("counter")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = store.IncrBy("counter", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), n)
+
+	n, err = store.IncrBy("counter", -12)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), n)
+
+	val, err := store.Get("counter")
+	assert.Nil(t, err)
+	assert.Equal(t, "-1", val)
+
+	assert.Nil(t, store.Set("text", "abc"))
+	_, err = store.Incr("text")
+	assert.Equal(t, turing.GeneralError, err)
+
+	n, err = store.HIncrBy("hash", "field", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+
+	n, err = store.HIncrBy("hash", "field", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), n)
+
+	hash, err := store.HGetAll("hash")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{ "field": "7" }, hash)
+}
+
+func testSets(t *testing.T, store turing.ExtendedKVStore) {
+	n, err := store.SAdd("set", "a", "b", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = store.SAdd("set", "c", "d")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	ok, err := store.SIsMember("set", "a")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.SIsMember("set", "z")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.SIsMember("missing", "a")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	n, err = store.SRem("set", "a", "z")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	members, err := store.SMembers("set")
+	assert.Nil(t, err)
+	sort.Strings(members)
+	assert.Equal(t, []string{ "b", "c", "d" }, members)
+
+	members, err = store.SMembers("missing")
+	assert.Nil(t, err)
+	assert.Empty(t, members)
+
+	store.SRem("set", "b", "c", "d")
+	n, err = store.Exists("set")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func testSortedSets(t *testing.T, store turing.ExtendedKVStore) {
+	n, err := store.ZAdd("zset",
+		turing.ZMember{ Member: "a", Score: 3 },
+		turing.ZMember{ Member: "b", Score: 1 },
+		turing.ZMember{ Member: "c", Score: 2 },
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = store.ZAdd("zset",
+		turing.ZMember{ Member: "a", Score: 0.5 },
+		turing.ZMember{ Member: "d", Score: 10 },
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	members, err := store.ZRangeByScore("zset", 0, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []turing.ZMember{
+		turing.ZMember{ Member: "a", Score: 0.5 },
+		turing.ZMember{ Member: "b", Score: 1 },
+		turing.ZMember{ Member: "c", Score: 2 },
+	}, members)
+
+	n, err = store.ZRem("zset", "b", "z")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	members, err = store.ZRangeByScore("zset", 1, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, []turing.ZMember{
+		turing.ZMember{ Member: "c", Score: 2 },
+		turing.ZMember{ Member: "d", Score: 10 },
+	}, members)
+
+	members, err = store.ZRangeByScore("missing", 0, 100)
+	assert.Nil(t, err)
+	assert.Empty(t, members)
+}
+
+func testLists(t *testing.T, store turing.ExtendedKVStore) {
+	n, err := store.LPush("list", "a", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = store.LPush("list", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	items, err := store.LRange("list", 0, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{ "c", "b", "a" }, items)
+
+	items, err = store.LRange("list", 1, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{ "b", "a" }, items)
+
+	items, err = store.LRange("list", -2, -2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{ "b" }, items)
+
+	items, err = store.LRange("list", 2, 1)
+	assert.Nil(t, err)
+	assert.Empty(t, items)
+
+	val, err := store.RPop("list")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", val)
+
+	store.RPop("list")
+	store.RPop("list")
+	_, err = store.RPop("list")
+	assert.Equal(t, turing.KeyNotExistsError, err)
+
+	items, err = store.LRange("list", 0, -1)
+	assert.Nil(t, err)
+	assert.Empty(t, items)
+}
+
+func testWrongType(t *testing.T, store turing.ExtendedKVStore) {
+	assert.Nil(t, store.Set("string", "abc"))
+	assert.Nil(t, store.HSet("hash", "field", "abc"))
+
+	_, err := store.Incr("hash")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.HIncrBy("string", "field", 1)
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.SAdd("string", "a")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.SMembers("hash")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.SIsMember("hash", "a")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.ZAdd("string", turing.ZMember{ Member: "a", Score: 1 })
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.ZRangeByScore("hash", 0, 1)
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.LPush("string", "a")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.RPop("hash")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.LRange("hash", 0, -1)
+	assert.Equal(t, turing.WrongTypeError, err)
+
+	store.SAdd("set", "a")
+	_, err = store.Get("set")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.HGetAll("set")
+	assert.Equal(t, turing.WrongTypeError, err)
+}
\ No newline at end of file
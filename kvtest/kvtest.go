@@ -0,0 +1,324 @@
+package kvtest
+
+import (
+	"testing"
+	"time"
+	"github.com/areller/turing"
+	"github.com/areller/turing/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// Factory creates a fresh store for every test. Stores that have a Close
+// method are closed when the test that created them finishes.
+type Factory func () turing.KVStore
+
+type Advance func (d time.Duration)
+
+// closeOnCleanup closes store, if it can be closed, once t finishes.
+func closeOnCleanup(t *testing.T, store interface{}) {
+	switch s := store.(type) {
+	case interface{ Close() }:
+		t.Cleanup(s.Close)
+	case interface{ Close() error }:
+		t.Cleanup(func () {
+			s.Close()
+		})
+	}
+}
+
+func Run(t *testing.T, factory Factory, advance Advance) {
+	if advance == nil {
+		advance = time.Sleep
+	}
+
+	run := func (name string, test func (t *testing.T, store turing.KVStore)) {
+		t.Run(name, func (t *testing.T) {
+			store := factory()
+			closeOnCleanup(t, store)
+			test(t, store)
+		})
+	}
+
+	run("Strings", testStrings)
+	run("DeleteExists", testDeleteExists)
+	run("Hashes", testHashes)
+	run("Expire", func (t *testing.T, store turing.KVStore) {
+		testExpire(t, store, advance)
+	})
+	run("BaseWrongType", testBaseWrongType)
+	run("Typed", testTyped)
+
+	store := factory()
+	closeOnCleanup(t, store)
+	if _, ok := store.(turing.ExtendedKVStore); ok {
+		RunExtendedSuite(t, func () turing.ExtendedKVStore {
+			return factory().(turing.ExtendedKVStore)
+		})
+	}
+	if _, ok := store.(turing.PipelineKVStore); ok {
+		RunPipelineSuite(t, func () turing.PipelineKVStore {
+			return factory().(turing.PipelineKVStore)
+		})
+	}
+	if _, ok := store.(turing.ScanKVStore); ok {
+		RunScanSuite(t, func () turing.ScanKVStore {
+			return factory().(turing.ScanKVStore)
+		})
+	}
+	if _, ok := store.(turing.LeaseKVStore); ok {
+		run("Lease", func (t *testing.T, store turing.KVStore) {
+			testLease(t, store.(turing.LeaseKVStore), advance)
+		})
+	}
+}
+
+func testStrings(t *testing.T, store turing.KVStore) {
+	_, err := store.Get("a")
+	assert.Equal(t, turing.KeyNotExistsError, err)
+
+	assert.Nil(t, store.Set("a", "value"))
+	val, err := store.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+
+	assert.Nil(t, store.Set("a", 42))
+	val, err = store.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "42", val)
+
+	assert.Nil(t, store.Set("b", 1.5))
+	val, _ = store.Get("b")
+	assert.Equal(t, "1.5", val)
+
+	assert.Nil(t, store.Set("c", true))
+	val, _ = store.Get("c")
+	assert.Equal(t, "1", val)
+
+	assert.Nil(t, store.Set("d", []byte("bytes")))
+	val, _ = store.Get("d")
+	assert.Equal(t, "bytes", val)
+}
+
+func testDeleteExists(t *testing.T, store turing.KVStore) {
+	store.Set("a", "1")
+	store.Set("b", "2")
+	store.HSet("c", "field", "3")
+
+	n, err := store.Exists("a", "b", "c", "missing")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = store.Exists("a", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = store.Delete("a", "c", "missing")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = store.Exists("a", "b", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = store.Delete("missing")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func testHashes(t *testing.T, store turing.KVStore) {
+	_, err := store.HGet("hash", "a")
+	assert.Equal(t, turing.KeyNotExistsError, err)
+
+	all, err := store.HGetAll("hash")
+	assert.Nil(t, err)
+	assert.Empty(t, all)
+
+	assert.Nil(t, store.HSet("hash", "a", "1"))
+	assert.Nil(t, store.HSet("hash", "b", 2))
+	assert.Nil(t, store.HSetMany("hash", map[string]interface{}{
+		"c": "3",
+		"d": 4.25,
+	}))
+
+	val, err := store.HGet("hash", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+
+	val, err = store.HGet("hash", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", val)
+
+	_, err = store.HGet("hash", "missing")
+	assert.Equal(t, turing.KeyNotExistsError, err)
+
+	all, err = store.HGetAll("hash")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{ "a": "1", "b": "2", "c": "3", "d": "4.25" }, all)
+
+	assert.Nil(t, store.HSetMany("empty", map[string]interface{}{}))
+	ex, err := store.Exists("empty")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ex)
+
+	n, err := store.HDelete("hash", "a", "b", "missing")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = store.HDelete("hash", "c", "d")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	n, err = store.Exists("hash")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	n, err = store.HDelete("missing", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func testExpire(t *testing.T, store turing.KVStore, advance Advance) {
+	assert.Equal(t, turing.KeyNotExistsError, store.Expire("missing", time.Second))
+
+	store.Set("a", "1")
+	store.HSet("b", "field", "1")
+	store.Set("c", "1")
+	assert.Nil(t, store.Expire("a", 50 * time.Millisecond))
+	assert.Nil(t, store.Expire("b", 50 * time.Millisecond))
+	assert.Nil(t, store.Expire("c", 50 * time.Millisecond))
+	assert.Nil(t, store.Set("c", "2"))
+
+	advance(100 * time.Millisecond)
+
+	_, err := store.Get("a")
+	assert.Equal(t, turing.KeyNotExistsError, err)
+	n, _ := store.Exists("a", "b", "c")
+	assert.Equal(t, 1, n)
+
+	assert.Nil(t, store.HSet("b", "other", "2"))
+	all, err := store.HGetAll("b")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{ "other": "2" }, all)
+}
+
+func testBaseWrongType(t *testing.T, store turing.KVStore) {
+	store.Set("string", "abc")
+	store.HSet("hash", "field", "abc")
+
+	_, err := store.Get("hash")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.HGet("string", "field")
+	assert.Equal(t, turing.WrongTypeError, err)
+	_, err = store.HGetAll("string")
+	assert.Equal(t, turing.WrongTypeError, err)
+	assert.Equal(t, turing.WrongTypeError, store.HSet("string", "field", "1"))
+	assert.Equal(t, turing.WrongTypeError, store.HSetMany("string", map[string]interface{}{ "field": "1" }))
+	_, err = store.HDelete("string", "field")
+	assert.Equal(t, turing.WrongTypeError, err)
+
+	assert.Nil(t, store.Set("hash", "overwritten"))
+	val, err := store.Get("hash")
+	assert.Nil(t, err)
+	assert.Equal(t, "overwritten", val)
+}
+
+func testLease(t *testing.T, store turing.LeaseKVStore, advance Advance) {
+	ok, err := store.SetNX("lease", "token1", 50 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.SetNX("lease", "token2", 50 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.RenewLease("lease", "token2", time.Second)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.RenewLease("lease", "token1", time.Second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	advance(100 * time.Millisecond)
+
+	ok, err = store.ReleaseLease("lease", "token2")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = store.ReleaseLease("lease", "token1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = store.SetNX("lease", "token2", 50 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	advance(100 * time.Millisecond)
+
+	ok, err = store.SetNX("lease", "token3", 50 * time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+type typedRecord struct {
+	Name string
+	Count int
+	Tags []string
+}
+
+func testTyped(t *testing.T, store turing.KVStore) {
+	plain := turing.NewTypedKVStore(store, new(turing.PlainValueCodec))
+	assert.Nil(t, plain.Set("int", int64(-42)))
+	assert.Nil(t, plain.HSetMany("hash", map[string]interface{}{
+		"float": 2.5,
+		"bool": true,
+	}))
+
+	var n int64
+	assert.Nil(t, plain.GetInto("int", &n))
+	assert.Equal(t, int64(-42), n)
+
+	var f float64
+	assert.Nil(t, plain.HGetInto("hash", "float", &f))
+	assert.Equal(t, 2.5, f)
+
+	var b bool
+	assert.Nil(t, plain.HGetInto("hash", "bool", &b))
+	assert.True(t, b)
+
+	assert.Nil(t, plain.Set("bytes", []byte{ 0xff, 0x00, 0x01 }))
+	var raw []byte
+	assert.Nil(t, plain.GetInto("bytes", &raw))
+	assert.Equal(t, []byte{ 0xff, 0x00, 0x01 }, raw)
+
+	assert.Equal(t, turing.KeyNotExistsError, plain.GetInto("missing", &n))
+	assert.Equal(t, turing.InvalidValueError, plain.HGetInto("hash", "float", &n))
+
+	json := turing.NewTypedKVStore(store, new(turing.JSONValueCodec))
+	record := typedRecord{
+		Name: "record",
+		Count: 3,
+		Tags: []string{ "a", "b" },
+	}
+	assert.Nil(t, json.HSet("records", "1", record))
+
+	var decoded typedRecord
+	assert.Nil(t, json.HGetInto("records", "1", &decoded))
+	assert.Equal(t, record, decoded)
+
+	protobuf := turing.NewTypedKVStore(store, new(turing.ProtobufValueCodec))
+	assert.Nil(t, protobuf.Set("proto", &proto.StringRequest{
+		Key: "binary\x00value",
+	}))
+
+	var msg proto.StringRequest
+	assert.Nil(t, protobuf.GetInto("proto", &msg))
+	assert.Equal(t, "binary\x00value", msg.Key)
+
+	str := turing.NewTypedKVStore(store, turing.ValueCodecFromCodec(new(turing.StringCodec)))
+	assert.Nil(t, str.Set("str", "hello"))
+
+	var s string
+	assert.Nil(t, str.GetInto("str", &s))
+	assert.Equal(t, "hello", s)
+}
\ No newline at end of file
@@ -0,0 +1,147 @@
+package kvtest
+
+import (
+	"testing"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+type PipelineFactory func () turing.PipelineKVStore
+
+func RunPipelineSuite(t *testing.T, factory PipelineFactory) {
+	run := func (name string, test func (t *testing.T, store turing.PipelineKVStore)) {
+		t.Run(name, func (t *testing.T) {
+			store := factory()
+			closeOnCleanup(t, store)
+			test(t, store)
+		})
+	}
+
+	run("Pipeline", func (t *testing.T, store turing.PipelineKVStore) {
+		testPipeline(t, store, false)
+	})
+	run("TxPipeline", func (t *testing.T, store turing.PipelineKVStore) {
+		testPipeline(t, store, true)
+	})
+	run("PipelineErrors", testPipelineErrors)
+	run("Watch", testWatch)
+}
+
+func testPipeline(t *testing.T, store turing.PipelineKVStore, tx bool) {
+	var pipe turing.Pipeline
+	if tx {
+		pipe = store.TxPipeline()
+	} else {
+		pipe = store.Pipeline()
+	}
+
+	set := pipe.Set("a", "1")
+	incr := pipe.IncrBy("a", 2)
+	get := pipe.Get("a")
+	missing := pipe.Get("b")
+	pipe.HSetMany("hash", map[string]interface{}{ "x": "1", "y": "2" })
+	hincr := pipe.HIncrBy("hash", "x", 5)
+	hget := pipe.HGet("hash", "y")
+	hgetAll := pipe.HGetAll("hash")
+	hdel := pipe.HDelete("hash", "y", "z")
+	exists := pipe.Exists("a", "b", "hash")
+	del := pipe.Delete("a", "b")
+
+	assert.Nil(t, pipe.Exec())
+	assert.Nil(t, set.Err)
+	assert.Equal(t, int64(3), incr.Value)
+	assert.Equal(t, "3", get.Value)
+	assert.Equal(t, turing.KeyNotExistsError, missing.Err)
+	assert.Equal(t, int64(6), hincr.Value)
+	assert.Equal(t, "2", hget.Value)
+	assert.Equal(t, map[string]string{ "x": "6", "y": "2" }, hgetAll.Value)
+	assert.Equal(t, 1, hdel.Value)
+	assert.Equal(t, 2, exists.Value)
+	assert.Equal(t, 1, del.Value)
+
+	n, err := store.Exists("a", "hash")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+}
+
+func testPipelineErrors(t *testing.T, store turing.PipelineKVStore) {
+	pipe := store.TxPipeline()
+	pipe.Set("a", "text")
+	incr := pipe.IncrBy("a", 1)
+	wrongType := pipe.HGet("a", "field")
+	set := pipe.Set("b", "1")
+
+	assert.Equal(t, turing.GeneralError, pipe.Exec())
+	assert.Equal(t, turing.GeneralError, incr.Err)
+	assert.Equal(t, turing.WrongTypeError, wrongType.Err)
+	assert.Nil(t, set.Err)
+
+	val, err := store.Get("b")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+}
+
+func testWatch(t *testing.T, store turing.PipelineKVStore) {
+	assert.Nil(t, store.Set("counter", "1"))
+
+	err := store.Watch(func (tx turing.Tx) error {
+		val, err := tx.Get("counter")
+		if err != nil {
+			return err
+		}
+
+		pipe := tx.TxPipeline()
+		pipe.Set("counter", val + "0")
+		return pipe.Exec()
+	}, "counter")
+	assert.Nil(t, err)
+
+	val, _ := store.Get("counter")
+	assert.Equal(t, "10", val)
+
+	err = store.Watch(func (tx turing.Tx) error {
+		_, err := tx.Get("counter")
+		if err != nil {
+			return err
+		}
+
+		store.Set("counter", "changed")
+
+		pipe := tx.TxPipeline()
+		res := pipe.Set("counter", "mine")
+		err = pipe.Exec()
+		assert.Equal(t, turing.TxFailedError, res.Err)
+		return err
+	}, "counter")
+	assert.Equal(t, turing.TxFailedError, err)
+
+	val, _ = store.Get("counter")
+	assert.Equal(t, "changed", val)
+
+	// A write that restores the watched value still aborts the transaction,
+	// as does creating and deleting a key that did not exist.
+	err = store.Watch(func (tx turing.Tx) error {
+		store.Set("counter", "other")
+		store.Set("counter", "changed")
+		store.Set("missing", "1")
+		store.Delete("missing")
+
+		pipe := tx.TxPipeline()
+		pipe.Set("counter", "mine")
+		return pipe.Exec()
+	}, "counter")
+	assert.Equal(t, turing.TxFailedError, err)
+
+	err = store.Watch(func (tx turing.Tx) error {
+		store.Set("missing", "1")
+		store.Delete("missing")
+
+		pipe := tx.TxPipeline()
+		pipe.Set("counter", "mine")
+		return pipe.Exec()
+	}, "missing")
+	assert.Equal(t, turing.TxFailedError, err)
+
+	val, _ = store.Get("counter")
+	assert.Equal(t, "changed", val)
+}
\ No newline at end of file
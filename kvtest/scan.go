@@ -0,0 +1,74 @@
+package kvtest
+
+import (
+	"sort"
+	"testing"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+type ScanFactory func () turing.ScanKVStore
+
+func RunScanSuite(t *testing.T, factory ScanFactory) {
+	run := func (name string, test func (t *testing.T, store turing.ScanKVStore)) {
+		t.Run(name, func (t *testing.T) {
+			store := factory()
+			closeOnCleanup(t, store)
+			test(t, store)
+		})
+	}
+
+	run("Scan", testScan)
+	run("HScan", testHScan)
+}
+
+func collectKeys(t *testing.T, it turing.Iterator) []string {
+	keys := []string{}
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	assert.Nil(t, it.Err())
+	sort.Strings(keys)
+	return keys
+}
+
+func testScan(t *testing.T, store turing.ScanKVStore) {
+	assert.Equal(t, []string{}, collectKeys(t, store.Scan("*", 10)))
+
+	for _, k := range []string{ "user:1", "user:2", "user:10", "order:1" } {
+		assert.Nil(t, store.Set(k, "v"))
+	}
+	assert.Nil(t, store.HSet("user:hash", "f", "v"))
+
+	assert.Equal(t, []string{ "order:1", "user:1", "user:10", "user:2", "user:hash" }, collectKeys(t, store.Scan("", 2)))
+	assert.Equal(t, []string{ "user:1", "user:10", "user:2", "user:hash" }, collectKeys(t, store.Scan("user:*", 2)))
+	assert.Equal(t, []string{ "user:1", "user:2" }, collectKeys(t, store.Scan("user:?", 2)))
+	assert.Equal(t, []string{ "user:1", "user:2" }, collectKeys(t, store.Scan("user:[12]", 2)))
+	assert.Equal(t, []string{}, collectKeys(t, store.Scan("nothing*", 2)))
+
+	_, err := store.Delete("user:1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{ "user:10", "user:2", "user:hash" }, collectKeys(t, store.Scan("user:*", 2)))
+}
+
+func testHScan(t *testing.T, store turing.ScanKVStore) {
+	it := store.HScan("hash", "*", 10)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+
+	assert.Nil(t, store.HSetMany("hash", map[string]interface{}{ "a1": "1", "a2": 2, "b1": "3" }))
+	fields := make(map[string]string)
+	it = store.HScan("hash", "", 1)
+	for it.Next() {
+		fields[it.Key()] = it.Value()
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, map[string]string{ "a1": "1", "a2": "2", "b1": "3" }, fields)
+
+	assert.Equal(t, []string{ "a1", "a2" }, collectKeys(t, store.HScan("hash", "a*", 1)))
+
+	assert.Nil(t, store.Set("str", "v"))
+	it = store.HScan("str", "*", 10)
+	assert.False(t, it.Next())
+	assert.Equal(t, turing.WrongTypeError, it.Err())
+}
\ No newline at end of file
@@ -0,0 +1,226 @@
+package turing
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type leadershipCallbacks struct {
+	gained func ()
+	lost func ()
+}
+
+type LeaderElector struct {
+	lock *Lock
+	interval time.Duration
+	leader int32
+	callbacksMutex sync.Mutex
+	callbacks []leadershipCallbacks
+	clock Clock
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func (le *LeaderElector) SetInterval(interval time.Duration) {
+	le.interval = interval
+}
+
+func (le *LeaderElector) SetClock(clock Clock) {
+	le.clock = clock
+	le.lock.SetClock(clock)
+}
+
+func (le *LeaderElector) AddCallbacks(gained func (), lost func ()) {
+	le.callbacksMutex.Lock()
+	defer le.callbacksMutex.Unlock()
+	le.callbacks = append(le.callbacks, leadershipCallbacks{
+		gained: gained,
+		lost: lost,
+	})
+}
+
+func (le *LeaderElector) IsLeader() bool {
+	return atomic.LoadInt32(&le.leader) == 1 && le.lock.Held()
+}
+
+func (le *LeaderElector) Lock() *Lock {
+	return le.lock
+}
+
+func (le *LeaderElector) notify(gained bool) {
+	le.callbacksMutex.Lock()
+	callbacks := le.callbacks
+	le.callbacksMutex.Unlock()
+
+	for _, c := range callbacks {
+		if gained && c.gained != nil {
+			c.gained()
+		} else if !gained && c.lost != nil {
+			c.lost()
+		}
+	}
+}
+
+func (le *LeaderElector) gain() {
+	Log.WithFields(LogFields{
+		"key": le.lock.Key(),
+	}).Info("Gained leadership")
+
+	atomic.StoreInt32(&le.leader, 1)
+	le.notify(true)
+}
+
+func (le *LeaderElector) lose() {
+	Log.WithFields(LogFields{
+		"key": le.lock.Key(),
+	}).Warn("Lost leadership")
+
+	atomic.StoreInt32(&le.leader, 0)
+	le.notify(false)
+}
+
+func (le *LeaderElector) step() {
+	if atomic.LoadInt32(&le.leader) == 0 {
+		ok, err := le.lock.TryAcquire()
+		if err != nil {
+			Log.WithError(err).Error("Could not acquire leadership lock")
+		} else if ok {
+			le.gain()
+		}
+		return
+	}
+
+	err := le.lock.Renew()
+	if err == LockNotHeldError {
+		le.lose()
+	} else if err != nil {
+		Log.WithError(err).Error("Could not renew leadership lock")
+		if !le.lock.Held() {
+			le.lose()
+		}
+	}
+}
+
+func (le *LeaderElector) Close() {
+	le.closeOnce.Do(func () {
+		close(le.closeChan)
+	})
+}
+
+func (le *LeaderElector) Run() error {
+	timer := le.clock.NewTimer(le.interval)
+	defer timer.Stop()
+
+	le.step()
+	for {
+		select {
+		case <- le.closeChan:
+			if atomic.LoadInt32(&le.leader) == 1 {
+				le.lose()
+				le.lock.Release()
+			}
+			return nil
+		case <- timer.C():
+			timer.Reset(le.interval)
+			le.step()
+		}
+	}
+}
+
+func NewLeaderElector(store LeaseKVStore, key string, ttl time.Duration) *LeaderElector {
+	return &LeaderElector{
+		lock: NewLock(store, key, ttl),
+		interval: ttl / 3,
+		clock: SystemClock,
+		closeChan: make(chan struct{}),
+	}
+}
+
+type LeaderRunnable struct {
+	elector *LeaderElector
+	factory RunnableFactory
+	mutex sync.Mutex
+	current Runnable
+	done chan struct{}
+	errChan chan error
+}
+
+func (lr *LeaderRunnable) fail(err error) {
+	select {
+	case lr.errChan <- err:
+	default:
+	}
+}
+
+func (lr *LeaderRunnable) start() {
+	runnable, err := lr.factory()
+	if err != nil {
+		Log.WithError(err).Error("Could not create leader runnable")
+		lr.fail(err)
+		return
+	}
+
+	done := make(chan struct{})
+	lr.mutex.Lock()
+	lr.current = runnable
+	lr.done = done
+	lr.mutex.Unlock()
+
+	go func () {
+		err := runnable.Run()
+		close(done)
+
+		lr.mutex.Lock()
+		stopped := lr.current != runnable
+		lr.mutex.Unlock()
+		if err != nil && !stopped {
+			lr.fail(err)
+		}
+	}()
+}
+
+func (lr *LeaderRunnable) stop() {
+	lr.mutex.Lock()
+	runnable, done := lr.current, lr.done
+	lr.current = nil
+	lr.mutex.Unlock()
+
+	if runnable == nil {
+		return
+	}
+
+	runnable.Close()
+	<- done
+}
+
+func (lr *LeaderRunnable) Close() {
+	lr.elector.Close()
+}
+
+func (lr *LeaderRunnable) Run() error {
+	lr.elector.AddCallbacks(lr.start, lr.stop)
+
+	electorErr := make(chan error, 1)
+	go func () {
+		electorErr <- lr.elector.Run()
+	}()
+
+	select {
+	case err := <- electorErr:
+		lr.stop()
+		return err
+	case err := <- lr.errChan:
+		lr.elector.Close()
+		<- electorErr
+		return err
+	}
+}
+
+func NewLeaderRunnable(elector *LeaderElector, factory RunnableFactory) *LeaderRunnable {
+	return &LeaderRunnable{
+		elector: elector,
+		factory: factory,
+		errChan: make(chan error, 1),
+	}
+}
\ No newline at end of file
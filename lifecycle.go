@@ -0,0 +1,151 @@
+package turing
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const DefaultShutdownTimeout = 30 * time.Second
+
+type Closer interface {
+	Close()
+}
+
+type Lifecycle struct {
+	stages [][]Closer
+	shutdownTimeout time.Duration
+	clock Clock
+	signals []os.Signal
+	shutdownChan chan struct{}
+	shutdownOnce sync.Once
+}
+
+func (lc *Lifecycle) AddStage(components ...Closer) {
+	lc.stages = append(lc.stages, components)
+}
+
+func (lc *Lifecycle) SetSignals(signals ...os.Signal) {
+	lc.signals = signals
+}
+
+func (lc *Lifecycle) SetClock(clock Clock) {
+	lc.clock = clock
+}
+
+func (lc *Lifecycle) Shutdown() {
+	lc.shutdownOnce.Do(func () {
+		close(lc.shutdownChan)
+	})
+}
+
+func (lc *Lifecycle) runnables() []Runnable {
+	var runnables []Runnable
+	for _, stage := range lc.stages {
+		for _, c := range stage {
+			if r, ok := c.(Runnable); ok {
+				runnables = append(runnables, r)
+			}
+		}
+	}
+	return runnables
+}
+
+func (lc *Lifecycle) closeStages() {
+	for i, stage := range lc.stages {
+		Log.WithFields(LogFields{
+			"stage": i,
+		}).Info("lifecycle: closing stage")
+
+		var wg sync.WaitGroup
+		for _, c := range stage {
+			wg.Add(1)
+			go func (closer Closer) {
+				closer.Close()
+				wg.Done()
+			}(c)
+		}
+		wg.Wait()
+	}
+}
+
+func (lc *Lifecycle) Run() error {
+	sigChan := make(chan os.Signal, 1)
+	if len(lc.signals) > 0 {
+		signal.Notify(sigChan, lc.signals...)
+		defer signal.Stop(sigChan)
+	}
+
+	runnables := lc.runnables()
+	results := make(chan error, len(runnables))
+	for _, r := range runnables {
+		go func (run Runnable) {
+			results <- run.Run()
+		}(r)
+	}
+
+	var runErr error
+	finished := 0
+	waiting := true
+	for waiting && finished < len(runnables) {
+		select {
+		case sig := <- sigChan:
+			Log.WithFields(LogFields{
+				"signal": sig.String(),
+			}).Info("lifecycle: received signal, shutting down")
+			waiting = false
+		case <- lc.shutdownChan:
+			waiting = false
+		case err := <- results:
+			finished++
+			if err != nil {
+				Log.WithError(err).Error("lifecycle: runnable failed, shutting down")
+				runErr = err
+				waiting = false
+			}
+		}
+	}
+
+	deadline := lc.clock.Now().Add(lc.shutdownTimeout)
+	if !tryWithTimeout(lc.clock, lc.shutdownTimeout, lc.closeStages) {
+		Log.WithFields(LogFields{
+			"timeout": lc.shutdownTimeout,
+		}).Error("lifecycle: shutdown did not complete in time")
+		return ShutdownTimeoutError
+	}
+
+	timer := lc.clock.NewTimer(deadline.Sub(lc.clock.Now()))
+	defer timer.Stop()
+	for finished < len(runnables) {
+		select {
+		case err := <- results:
+			finished++
+			if err != nil && runErr == nil {
+				runErr = err
+			}
+		case <- timer.C():
+			return ShutdownTimeoutError
+		}
+	}
+
+	return runErr
+}
+
+func NewLifecycle(shutdownTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		shutdownTimeout: shutdownTimeout,
+		clock: SystemClock,
+		signals: []os.Signal{ os.Interrupt, syscall.SIGTERM },
+		shutdownChan: make(chan struct{}),
+	}
+}
+
+func ExitCode(err error) int {
+	if err != nil {
+		return 1
+	}
+
+	return 0
+}
\ No newline at end of file
@@ -0,0 +1,83 @@
+package turing
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleClosesStagesInOrder(t *testing.T) {
+	var mutex sync.Mutex
+	var order []string
+	closeChan := make(chan struct{})
+
+	record := func (name string) func () {
+		return func () {
+			mutex.Lock()
+			order = append(order, name)
+			mutex.Unlock()
+		}
+	}
+
+	blocking := func (name string) *annonymousRunnable {
+		done := make(chan struct{})
+		return newAnnonymousRunner(func () error {
+			<- done
+			return nil
+		}, func () {
+			record(name)()
+			close(done)
+		})
+	}
+
+	lc := NewLifecycle(time.Second)
+	lc.AddStage(blocking("processor"))
+	lc.AddStage(blocking("producer"))
+	lc.AddStage(newAnnonymousRunner(func () error {
+		<- closeChan
+		return nil
+	}, func () {
+		record("store")()
+		close(closeChan)
+	}))
+
+	go lc.Shutdown()
+	assert.Nil(t, lc.Run())
+	assert.Equal(t, []string{ "processor", "producer", "store" }, order)
+}
+
+func TestLifecyclePropagatesRunError(t *testing.T) {
+	runErr := errors.New("consumer died")
+	closed := make(chan struct{})
+
+	lc := NewLifecycle(time.Second)
+	lc.AddStage(newAnnonymousRunner(func () error {
+		return runErr
+	}, func () {
+
+	}))
+	lc.AddStage(newAnnonymousRunner(func () error {
+		<- closed
+		return nil
+	}, func () {
+		close(closed)
+	}))
+
+	err := lc.Run()
+	assert.Equal(t, runErr, err)
+	assert.Equal(t, 1, ExitCode(err))
+}
+
+func TestLifecycleShutdownTimeout(t *testing.T) {
+	lc := NewLifecycle(50 * time.Millisecond)
+	lc.AddStage(newAnnonymousRunner(func () error {
+		select {}
+	}, func () {
+		time.Sleep(time.Second)
+	}))
+
+	lc.Shutdown()
+	assert.Equal(t, ShutdownTimeoutError, lc.Run())
+}
\ No newline at end of file
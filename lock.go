@@ -0,0 +1,109 @@
+package turing
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Lock struct {
+	store LeaseKVStore
+	key string
+	ttl time.Duration
+	instanceId string
+	acquisitions int64
+	clock Clock
+	mutex sync.Mutex
+	token string
+	deadline time.Time
+}
+
+// SetClock sets the clock the lock measures its deadline with, it should
+// match the clock of the store.
+func (l *Lock) SetClock(clock Clock) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.clock = clock
+}
+
+func (l *Lock) Key() string {
+	return l.key
+}
+
+func (l *Lock) Token() string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.token
+}
+
+func (l *Lock) Held() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.token != "" && l.clock.Now().Before(l.deadline)
+}
+
+func (l *Lock) TryAcquire() (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.acquisitions++
+	token := fmt.Sprintf("%s-%d", l.instanceId, l.acquisitions)
+	now := l.clock.Now()
+	ok, err := l.store.SetNX(l.key, token, l.ttl)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	l.token = token
+	l.deadline = now.Add(l.ttl)
+	return true, nil
+}
+
+func (l *Lock) Renew() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.token == "" {
+		return LockNotHeldError
+	}
+
+	now := l.clock.Now()
+	ok, err := l.store.RenewLease(l.key, l.token, l.ttl)
+	if err != nil {
+		return err
+	} else if !ok {
+		l.token = ""
+		return LockNotHeldError
+	}
+
+	l.deadline = now.Add(l.ttl)
+	return nil
+}
+
+func (l *Lock) Release() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.token == "" {
+		return LockNotHeldError
+	}
+
+	token := l.token
+	l.token = ""
+	ok, err := l.store.ReleaseLease(l.key, token)
+	if err != nil {
+		return err
+	} else if !ok {
+		return LockNotHeldError
+	}
+
+	return nil
+}
+
+func NewLock(store LeaseKVStore, key string, ttl time.Duration) *Lock {
+	return &Lock{
+		store: store,
+		key: key,
+		ttl: ttl,
+		instanceId: newInstanceId(),
+		clock: SystemClock,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package turing
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockExclusive(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	lock1 := NewLock(store, "lock", time.Minute)
+	lock2 := NewLock(store, "lock", time.Minute)
+
+	ok, err := lock1.TryAcquire()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, lock1.Held())
+
+	ok, err = lock2.TryAcquire()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.False(t, lock2.Held())
+	assert.Equal(t, LockNotHeldError, lock2.Renew())
+	assert.Equal(t, LockNotHeldError, lock2.Release())
+
+	assert.Nil(t, lock1.Renew())
+	assert.Nil(t, lock1.Release())
+	assert.False(t, lock1.Held())
+
+	ok, _ = lock2.TryAcquire()
+	assert.True(t, ok)
+}
+
+func TestLockFencedRenewal(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	lock1 := NewLock(store, "lock", 20 * time.Millisecond)
+	lock2 := NewLock(store, "lock", time.Minute)
+
+	ok, _ := lock1.TryAcquire()
+	assert.True(t, ok)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, lock1.Held())
+
+	ok, _ = lock2.TryAcquire()
+	assert.True(t, ok)
+
+	assert.Equal(t, LockNotHeldError, lock1.Renew())
+	assert.True(t, lock2.Held())
+	assert.NotEqual(t, lock1.Token(), lock2.Token())
+}
+
+func TestLeaderElection(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	gained := make(chan int, 2)
+	lost := make(chan int, 2)
+	var electors []*LeaderElector
+	for i := 0; i < 2; i++ {
+		id := i
+		elector := NewLeaderElector(store, "leader", 60 * time.Millisecond)
+		elector.AddCallbacks(func () {
+			gained <- id
+		}, func () {
+			lost <- id
+		})
+		electors = append(electors, elector)
+		go elector.Run()
+	}
+
+	first := <- gained
+	assert.True(t, electors[first].IsLeader())
+	assert.False(t, electors[1 - first].IsLeader())
+
+	electors[first].Close()
+	assert.Equal(t, first, <- lost)
+
+	select {
+	case second := <- gained:
+		assert.Equal(t, 1 - first, second)
+	case <- time.After(time.Second):
+		t.Fatal("leadership was not taken over")
+	}
+
+	electors[1 - first].Close()
+}
+
+func TestLeaderRunnable(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	running := int32(0)
+	started := make(chan struct{}, 1)
+	lr := NewLeaderRunnable(NewLeaderElector(store, "leader", time.Minute), func () (Runnable, error) {
+		closeChan := make(chan struct{})
+		return newAnnonymousRunner(func () error {
+			atomic.StoreInt32(&running, 1)
+			started <- struct{}{}
+			<- closeChan
+			atomic.StoreInt32(&running, 0)
+			return nil
+		}, func () {
+			close(closeChan)
+		}), nil
+	})
+
+	done := make(chan error)
+	go func () {
+		done <- lr.Run()
+	}()
+
+	<- started
+	assert.Equal(t, int32(1), atomic.LoadInt32(&running))
+
+	lr.Close()
+	assert.Nil(t, <- done)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&running))
+}
\ No newline at end of file
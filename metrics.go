@@ -0,0 +1,75 @@
+package turing
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type Metrics interface {
+	MessageConsumed(topic string, partition int64)
+	MessageHandled(topic string, partition int64, duration time.Duration)
+	MessageFailed(topic string, partition int64)
+	HandlerRetried(topic string, partition int64)
+	Committed(topic string, partition int64, duration time.Duration)
+	CommitFailed(topic string, partition int64)
+	ProducerSent(topic string, duration time.Duration, err error)
+	PartitionAssigned(topic string, partition int64)
+	PartitionRevoked(topic string, partition int64)
+	KVStoreOperation(operation string, duration time.Duration, err error)
+	RunnableRestarted(supervisor string, name string)
+	KVStoreCacheLookup(operation string, hit bool)
+}
+
+type noopMetrics struct {
+
+}
+
+func (nm noopMetrics) MessageConsumed(topic string, partition int64) { }
+
+func (nm noopMetrics) MessageHandled(topic string, partition int64, duration time.Duration) { }
+
+func (nm noopMetrics) MessageFailed(topic string, partition int64) { }
+
+func (nm noopMetrics) HandlerRetried(topic string, partition int64) { }
+
+func (nm noopMetrics) Committed(topic string, partition int64, duration time.Duration) { }
+
+func (nm noopMetrics) CommitFailed(topic string, partition int64) { }
+
+func (nm noopMetrics) ProducerSent(topic string, duration time.Duration, err error) { }
+
+func (nm noopMetrics) PartitionAssigned(topic string, partition int64) { }
+
+func (nm noopMetrics) PartitionRevoked(topic string, partition int64) { }
+
+func (nm noopMetrics) KVStoreOperation(operation string, duration time.Duration, err error) { }
+
+func (nm noopMetrics) RunnableRestarted(supervisor string, name string) { }
+
+func (nm noopMetrics) KVStoreCacheLookup(operation string, hit bool) { }
+
+type metricsHolder struct {
+	metrics Metrics
+}
+
+var currentMetrics atomic.Value
+
+func init() {
+	currentMetrics.Store(metricsHolder{
+		metrics: noopMetrics{},
+	})
+}
+
+func GetMetrics() Metrics {
+	return currentMetrics.Load().(metricsHolder).metrics
+}
+
+func SetMetrics(metrics Metrics) {
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+
+	currentMetrics.Store(metricsHolder{
+		metrics: metrics,
+	})
+}
\ No newline at end of file
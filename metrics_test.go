@@ -0,0 +1,168 @@
+package turing
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingMetrics struct {
+	noopMetrics
+	mutex sync.Mutex
+	counts map[string]int
+	committed chan struct{}
+}
+
+func (rm *recordingMetrics) inc(name string) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	rm.counts[name]++
+}
+
+func (rm *recordingMetrics) get(name string) int {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+	return rm.counts[name]
+}
+
+func (rm *recordingMetrics) MessageConsumed(topic string, partition int64) {
+	rm.inc("consumed")
+}
+
+func (rm *recordingMetrics) MessageHandled(topic string, partition int64, duration time.Duration) {
+	rm.inc("handled")
+}
+
+func (rm *recordingMetrics) MessageFailed(topic string, partition int64) {
+	rm.inc("failed")
+}
+
+func (rm *recordingMetrics) HandlerRetried(topic string, partition int64) {
+	rm.inc("retried")
+}
+
+func (rm *recordingMetrics) Committed(topic string, partition int64, duration time.Duration) {
+	rm.inc("committed")
+	rm.committed <- struct{}{}
+}
+
+func (rm *recordingMetrics) CommitFailed(topic string, partition int64) {
+	rm.inc("commit_failed")
+}
+
+func (rm *recordingMetrics) PartitionAssigned(topic string, partition int64) {
+	rm.inc("assigned")
+}
+
+func (rm *recordingMetrics) KVStoreOperation(operation string, duration time.Duration, err error) {
+	rm.inc("kv_" + operation)
+}
+
+type stubKVStore struct {
+	KVStore
+}
+
+func (sks stubKVStore) Set(key string, value interface{}) error {
+	return nil
+}
+
+func (sks stubKVStore) Get(key string) (string, error) {
+	return "", KeyNotExistsError
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		counts: make(map[string]int),
+		committed: make(chan struct{}, 1),
+	}
+}
+
+func TestProcessorMetrics(t *testing.T) {
+	metrics := newRecordingMetrics()
+	SetMetrics(metrics)
+	defer SetMetrics(nil)
+
+	attempts := 0
+	consumer := NewConsumerMock()
+	sp, _ := NewSimpleProcessor(consumer, nil, []SimpleProcessorTopicDefinition{
+		SimpleProcessorTopicDefinition{
+			Name: "myTopic",
+			Codec: new(StringCodec),
+			Handler: func (ctx SimpleProcessorContext, msg DecodedKV) (error, bool) {
+				attempts++
+				if attempts == 1 {
+					return errors.New("try again"), false
+				}
+				return nil, true
+			},
+		},
+	})
+
+	go sp.Run()
+	defer sp.Close()
+
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 0,
+	})
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 1,
+	})
+	consumer.CreateMessageEvent(MessageEvent{
+		Topic: "myTopic",
+		PartitionId: 0,
+		Offset: 0,
+		Key: []byte("myKey"),
+		Value: []byte("My Message"),
+	})
+
+	<- metrics.committed
+	assert.True(t, metrics.get("assigned") >= 1)
+	assert.Equal(t, 1, metrics.get("consumed"))
+	assert.Equal(t, 2, metrics.get("handled"))
+	assert.Equal(t, 1, metrics.get("failed"))
+	assert.Equal(t, 1, metrics.get("retried"))
+}
+
+func TestCommitFailedMetrics(t *testing.T) {
+	metrics := newRecordingMetrics()
+	SetMetrics(metrics)
+	defer SetMetrics(nil)
+
+	flaky := newFlakyKVStore(100)
+	defer flaky.Close()
+	store := NewResilientKVStore(flaky)
+	store.SetRetries(1, time.Millisecond, time.Millisecond)
+
+	sp := &SimpleProcessor{}
+	sp.SetKVStoreCommit("group", store)
+
+	sp.commit(NewPartition("topic", 1), MessageEvent{ Offset: 10 })
+	assert.Equal(t, 1, metrics.get("commit_failed"))
+	assert.Equal(t, 0, metrics.get("committed"))
+
+	flaky.failures = 0
+	sp.commit(NewPartition("topic", 1), MessageEvent{ Offset: 10 })
+	<- metrics.committed
+	assert.Equal(t, 1, metrics.get("commit_failed"))
+	assert.Equal(t, 1, metrics.get("committed"))
+}
+
+func TestInstrumentedKVStore(t *testing.T) {
+	metrics := newRecordingMetrics()
+	SetMetrics(metrics)
+	defer SetMetrics(nil)
+
+	store := InstrumentKVStore(stubKVStore{})
+	store.Set("a", "b")
+	store.Get("a")
+	store.Get("a")
+
+	assert.Equal(t, 1, metrics.get("kv_set"))
+	assert.Equal(t, 2, metrics.get("kv_get"))
+}
\ No newline at end of file
@@ -1,5 +1,13 @@
 package turing
 
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 type PartitionHandler func (partition *Partition, original EncodedKV, message DecodedKV)
 type PartitionCommitHandler func (partition *Partition, message MessageEvent)
 
@@ -11,12 +19,19 @@ const (
 )
 
 type Partition struct {
+	ctx context.Context
+	cancel context.CancelFunc
+	aborted bool
+	running int32
+	handlingSince int64
 	offset int64
 	offsetChan chan int64
 	closeChan chan struct{}
+	closeOnce sync.Once
 	handler PartitionHandler
 	commitHandler PartitionCommitHandler
 	codec Codec
+	clock Clock
 
 	Topic string
 	Id int64
@@ -24,22 +39,69 @@ type Partition struct {
 }
 
 func (p *Partition) handleMessageEvent(msg MessageEvent) {
+	atomic.StoreInt64(&p.handlingSince, p.clock.Now().UnixNano())
+	defer atomic.StoreInt64(&p.handlingSince, 0)
+
 	p.offset = msg.Offset
+	p.aborted = false
+	GetMetrics().MessageConsumed(p.Topic, p.Id)
 	decoded, err := p.codec.Decode(msg.Key, msg.Value)
-	if err == nil {
+	if err != nil {
+		GetMetrics().MessageFailed(p.Topic, p.Id)
+	} else {
 		p.handler(p, EncodedKV{
 			Key: msg.Key,
 			Value: msg.Value,
+			Headers: msg.Headers,
 		}, decoded)
 
-		if p.commitHandler != nil {
+		if p.commitHandler != nil && !p.aborted {
 			p.commitHandler(p, msg)
 		}
 	}
 }
 
+func (p *Partition) abort() {
+	p.aborted = true
+}
+
+func (p *Partition) stop() {
+	p.closeOnce.Do(func () {
+		close(p.closeChan)
+	})
+}
+
 func (p *Partition) Close() {
-	close(p.closeChan)
+	p.cancel()
+	p.stop()
+}
+
+func (p *Partition) SetContext(parent context.Context) {
+	p.cancel()
+	p.ctx, p.cancel = context.WithCancel(parent)
+}
+
+func (p *Partition) Context() context.Context {
+	return p.ctx
+}
+
+func (p *Partition) String() string {
+	return p.Topic + "_" + strconv.FormatInt(p.Id, 10)
+}
+
+func (p *Partition) IsRunning() bool {
+	return atomic.LoadInt32(&p.running) == 1
+}
+
+func (p *Partition) Stuck(threshold time.Duration) bool {
+	since := atomic.LoadInt64(&p.handlingSince)
+	return since != 0 && p.clock.Now().Sub(time.Unix(0, since)) > threshold
+}
+
+// SetClock sets the clock the partition measures how long it has been
+// handling a message with.
+func (p *Partition) SetClock(clock Clock) {
+	p.clock = clock
 }
 
 func (p *Partition) SetCodec(codec Codec) {
@@ -75,6 +137,9 @@ func (p *Partition) Run() error {
 		p.offset = OffsetStored
 	}
 
+	atomic.StoreInt32(&p.running, 1)
+	defer atomic.StoreInt32(&p.running, 0)
+
 	p.offsetChan <- p.offset
 
 	for {
@@ -88,11 +153,15 @@ func (p *Partition) Run() error {
 }
 
 func NewPartition(topic string, partitionId int64) *Partition {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Partition{
+		ctx: ctx,
+		cancel: cancel,
 		closeChan: make(chan struct{}),
 		offset: OffsetNone,
 		offsetChan: make(chan int64, 1),
 		commitHandler: nil,
+		clock: SystemClock,
 		Topic: topic,
 		Id: partitionId,
 		Messages: make(chan MessageEvent),
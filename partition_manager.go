@@ -42,13 +42,21 @@ func (pm *PartitionManager) handlePartitionEvent(ev PartitionEvent) {
 			closeChan: make(chan struct{}),
 		}
 		go pm.partitions[id].run()
-		pm.CreatedPartition <- part
+		GetMetrics().PartitionAssigned(ev.Topic, ev.Id)
+		select {
+		case pm.CreatedPartition <- part:
+		case <- pm.closeChan:
+		}
 	case PartitionDestroyed:
 		part, ok := pm.partitions[ev.String()]
 		if ok {
 			delete(pm.partitions, ev.String())
 			part.close()
-			pm.RemovedPartition <- part.partition
+			GetMetrics().PartitionRevoked(ev.Topic, ev.Id)
+			select {
+			case pm.RemovedPartition <- part.partition:
+			case <- pm.closeChan:
+			}
 		} else {
 			pm.Errors <- NoPartitionError
 		}
@@ -61,7 +69,10 @@ func (pm *PartitionManager) handleMessageEvent(ev MessageEvent) {
 		return
 	}
 
-	part.partition.Messages <- ev
+	select {
+	case part.partition.Messages <- ev:
+	case <- pm.closeChan:
+	}
 }
 
 func (pm *PartitionManager) Close() {
@@ -0,0 +1,41 @@
+package turing
+
+import (
+	"time"
+)
+
+type PipelineResult struct {
+	Value interface{}
+	Err error
+}
+
+type Pipeline interface {
+	Set(key string, value interface{}) *PipelineResult
+	Get(key string) *PipelineResult
+	Delete(keys ...string) *PipelineResult
+	Exists(keys ...string) *PipelineResult
+	HSet(key string, field string, value interface{}) *PipelineResult
+	HSetMany(key string, kv map[string]interface{}) *PipelineResult
+	HGet(key string, field string) *PipelineResult
+	HGetAll(key string) *PipelineResult
+	HDelete(key string, fields ...string) *PipelineResult
+	Expire(key string, expiry time.Duration) *PipelineResult
+	IncrBy(key string, value int64) *PipelineResult
+	HIncrBy(key string, field string, value int64) *PipelineResult
+	Exec() error
+}
+
+type Tx interface {
+	Get(key string) (string, error)
+	Exists(keys ...string) (int, error)
+	HGet(key string, field string) (string, error)
+	HGetAll(key string) (map[string]string, error)
+	TxPipeline() Pipeline
+}
+
+type PipelineKVStore interface {
+	KVStore
+	Pipeline() Pipeline
+	TxPipeline() Pipeline
+	Watch(fn func (tx Tx) error, keys ...string) error
+}
\ No newline at end of file
@@ -1,5 +1,19 @@
 package turing
 
+import (
+	"context"
+)
+
 type Producer interface {
 	Send(topic string, key []byte, msg []byte) error
+}
+
+type HeaderProducer interface {
+	Producer
+	SendWithHeaders(topic string, key []byte, msg []byte, headers []Header) error
+}
+
+type ContextProducer interface {
+	Producer
+	SendContext(ctx context.Context, topic string, key []byte, msg []byte, headers []Header) error
 }
\ No newline at end of file
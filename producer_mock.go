@@ -1,27 +1,300 @@
 package turing
 
-type producerMockMessage struct {
+import (
+	"context"
+	"sync"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+type SentMessage struct {
+	Topic string
+	Key []byte
+	Value []byte
+	Headers []Header
+	Time time.Time
+	Err error
+}
+
+func (sm SentMessage) Decode(codec Codec) (DecodedKV, error) {
+	return codec.Decode(sm.Key, sm.Value)
+}
+
+// producerMockRule matches every key of topic when key is nil. A failure
+// rule with times set to zero or less applies forever.
+type producerMockRule struct {
 	topic string
-	key []byte
-	value []byte
+	key *string
+	err error
+	delay time.Duration
+	times int
+	used int
+}
+
+func (pmr *producerMockRule) matches(topic string, key []byte) bool {
+	if pmr.times > 0 && pmr.used >= pmr.times {
+		return false
+	}
+	return pmr.topic == topic && (pmr.key == nil || *pmr.key == string(key))
 }
 
 type ProducerMock struct {
-	SentMessages chan producerMockMessage
+	sent []SentMessage
+	failed []SentMessage
+	codecs map[string]Codec
+	rules []*producerMockRule
+	clock Clock
+	changed *sync.Cond
+	mutex sync.Mutex
 }
 
-func (pm *ProducerMock) Send(topic string, key []byte, msg []byte) error {
-	pm.SentMessages <- producerMockMessage{
+func (pm *ProducerMock) addRule(rule *producerMockRule) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.rules = append(pm.rules, rule)
+}
+
+// FailSends makes the next times sends to topic fail with err, or all of
+// them if times is zero or less.
+func (pm *ProducerMock) FailSends(topic string, err error, times int) {
+	pm.addRule(&producerMockRule{
 		topic: topic,
-		key: key,
-		value: msg,
+		err: err,
+		times: times,
+	})
+}
+
+func (pm *ProducerMock) FailSendsWithKey(topic string, key string, err error, times int) {
+	pm.addRule(&producerMockRule{
+		topic: topic,
+		key: &key,
+		err: err,
+		times: times,
+	})
+}
+
+// DelaySends holds every send to topic for delay, or until its context is
+// done, before it is delivered.
+func (pm *ProducerMock) DelaySends(topic string, delay time.Duration) {
+	pm.addRule(&producerMockRule{
+		topic: topic,
+		delay: delay,
+	})
+}
+
+func (pm *ProducerMock) DelaySendsWithKey(topic string, key string, delay time.Duration) {
+	pm.addRule(&producerMockRule{
+		topic: topic,
+		key: &key,
+		delay: delay,
+	})
+}
+
+func (pm *ProducerMock) SetCodec(topic string, codec Codec) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.codecs[topic] = codec
+}
+
+func (pm *ProducerMock) SetClock(clock Clock) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.clock = clock
+}
+
+func (pm *ProducerMock) script(topic string, key []byte) (time.Duration, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	var delay time.Duration
+	var err error
+	for _, rule := range pm.rules {
+		if !rule.matches(topic, key) {
+			continue
+		}
+
+		if rule.delay > 0 {
+			if delay == 0 {
+				delay = rule.delay
+			}
+		} else if err == nil {
+			err = rule.err
+			rule.used++
+		}
+	}
+	return delay, err
+}
+
+func (pm *ProducerMock) wait(ctx context.Context, delay time.Duration) error {
+	pm.mutex.Lock()
+	clock := pm.clock
+	pm.mutex.Unlock()
+
+	timer := clock.NewTimer(delay)
+	select {
+	case <- ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <- timer.C():
+		return nil
 	}
+}
 
-	return nil
+func (pm *ProducerMock) SendContext(ctx context.Context, topic string, key []byte, msg []byte, headers []Header) error {
+	delay, err := pm.script(topic, key)
+	if delay > 0 {
+		if waitErr := pm.wait(ctx, delay); waitErr != nil {
+			err = waitErr
+		}
+	} else if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	sent := SentMessage{
+		Topic: topic,
+		Key: key,
+		Value: msg,
+		Headers: headers,
+		Time: pm.clock.Now(),
+		Err: err,
+	}
+	if err != nil {
+		pm.failed = append(pm.failed, sent)
+	} else {
+		pm.sent = append(pm.sent, sent)
+	}
+	pm.changed.Broadcast()
+	return err
+}
+
+func (pm *ProducerMock) Send(topic string, key []byte, msg []byte) error {
+	return pm.SendContext(context.Background(), topic, key, msg, nil)
+}
+
+func (pm *ProducerMock) SendWithHeaders(topic string, key []byte, msg []byte, headers []Header) error {
+	return pm.SendContext(context.Background(), topic, key, msg, headers)
+}
+
+// Messages returns every message that was delivered, in order.
+func (pm *ProducerMock) Messages() []SentMessage {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	sent := make([]SentMessage, len(pm.sent))
+	copy(sent, pm.sent)
+	return sent
+}
+
+// Failed returns every send that returned an error.
+func (pm *ProducerMock) Failed() []SentMessage {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	failed := make([]SentMessage, len(pm.failed))
+	copy(failed, pm.failed)
+	return failed
+}
+
+func (pm *ProducerMock) MessagesTo(topic string) []SentMessage {
+	var sent []SentMessage
+	for _, m := range pm.Messages() {
+		if m.Topic == topic {
+			sent = append(sent, m)
+		}
+	}
+	return sent
+}
+
+func (pm *ProducerMock) MessagesWithKey(topic string, key string) []SentMessage {
+	var sent []SentMessage
+	for _, m := range pm.MessagesTo(topic) {
+		if string(m.Key) == key {
+			sent = append(sent, m)
+		}
+	}
+	return sent
+}
+
+// Decoded decodes the messages delivered to topic with the codec set for it.
+func (pm *ProducerMock) Decoded(topic string) ([]DecodedKV, error) {
+	pm.mutex.Lock()
+	codec, ok := pm.codecs[topic]
+	pm.mutex.Unlock()
+	if !ok {
+		return nil, NoCodecError
+	}
+
+	var decoded []DecodedKV
+	for _, m := range pm.MessagesTo(topic) {
+		kv, err := m.Decode(codec)
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, kv)
+	}
+	return decoded, nil
+}
+
+// WaitForMessages blocks until at least n messages have been delivered, it
+// returns false if that did not happen within timeout.
+func (pm *ProducerMock) WaitForMessages(n int, timeout time.Duration) bool {
+	expired := false
+	timer := time.AfterFunc(timeout, func () {
+		pm.mutex.Lock()
+		defer pm.mutex.Unlock()
+		expired = true
+		pm.changed.Broadcast()
+	})
+	defer timer.Stop()
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	for len(pm.sent) < n && !expired {
+		pm.changed.Wait()
+	}
+	return len(pm.sent) >= n
+}
+
+func (pm *ProducerMock) Reset() {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	pm.sent = nil
+	pm.failed = nil
+	pm.rules = nil
+}
+
+func (pm *ProducerMock) AssertSent(t assert.TestingT, topic string, times int) bool {
+	return assert.Len(t, pm.MessagesTo(topic), times, "messages sent to %s", topic)
+}
+
+func (pm *ProducerMock) AssertSentWithKey(t assert.TestingT, topic string, key string, times int) bool {
+	return assert.Len(t, pm.MessagesWithKey(topic, key), times, "messages sent to %s with key %s", topic, key)
+}
+
+func (pm *ProducerMock) AssertNotSent(t assert.TestingT, topic string) bool {
+	return pm.AssertSent(t, topic, 0)
+}
+
+// AssertSentValue checks that a message with key was sent to topic and that
+// its value, decoded with the topic's codec, equals value.
+func (pm *ProducerMock) AssertSentValue(t assert.TestingT, topic string, key string, value interface{}) bool {
+	decoded, err := pm.Decoded(topic)
+	if !assert.NoError(t, err) {
+		return false
+	}
+
+	for _, kv := range decoded {
+		if kv.Key == key && assert.ObjectsAreEqual(value, kv.Value) {
+			return true
+		}
+	}
+	return assert.Fail(t, "message not sent", "no message sent to %s with key %s and value %v", topic, key, value)
 }
 
 func NewProducerMock() *ProducerMock {
-	return &ProducerMock{
-		SentMessages: make(chan producerMockMessage, 1),
+	pm := &ProducerMock{
+		codecs: make(map[string]Codec),
+		clock: SystemClock,
 	}
+	pm.changed = sync.NewCond(&pm.mutex)
+	return pm
 }
\ No newline at end of file
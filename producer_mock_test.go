@@ -0,0 +1,99 @@
+package turing
+
+import (
+	"context"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingT struct {
+	failures int
+}
+
+func (ft *failingT) Errorf(format string, args ...interface{}) {
+	ft.failures++
+}
+
+func TestProducerMockRecords(t *testing.T) {
+	pm := NewProducerMock()
+	pm.SetCodec("users", new(StringCodec))
+	tp := NewTopicProducer("users", new(StringCodec), pm)
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, tp.Send("alice", "hello"))
+	}
+	assert.Nil(t, tp.Send("bob", "bye"))
+	assert.Nil(t, pm.Send("other", nil, []byte("x")))
+
+	assert.Len(t, pm.Messages(), 5)
+	pm.AssertSent(t, "users", 4)
+	pm.AssertSentWithKey(t, "users", "alice", 3)
+	pm.AssertSentWithKey(t, "users", "carol", 0)
+	pm.AssertNotSent(t, "missing")
+	pm.AssertSentValue(t, "users", "bob", "bye")
+
+	decoded, err := pm.Decoded("users")
+	assert.Nil(t, err)
+	assert.Equal(t, DecodedKV{ Key: "bob", Value: "bye" }, decoded[3])
+	_, err = pm.Decoded("other")
+	assert.Equal(t, NoCodecError, err)
+
+	ft := new(failingT)
+	assert.False(t, pm.AssertSent(ft, "users", 1))
+	assert.False(t, pm.AssertSentValue(ft, "users", "bob", "hello"))
+	assert.Equal(t, 2, ft.failures)
+
+	pm.Reset()
+	assert.Empty(t, pm.Messages())
+}
+
+func TestProducerMockFailures(t *testing.T) {
+	pm := NewProducerMock()
+	pm.FailSends("orders", ConnectionDroppedError, 2)
+	pm.FailSendsWithKey("users", "alice", InvalidValueError, 0)
+
+	assert.Equal(t, ConnectionDroppedError, pm.Send("orders", []byte("1"), nil))
+	assert.Equal(t, ConnectionDroppedError, pm.Send("orders", []byte("2"), nil))
+	assert.Nil(t, pm.Send("orders", []byte("3"), nil))
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, InvalidValueError, pm.Send("users", []byte("alice"), nil))
+	}
+	assert.Nil(t, pm.Send("users", []byte("bob"), nil))
+
+	assert.Len(t, pm.Failed(), 5)
+	assert.Equal(t, ConnectionDroppedError, pm.Failed()[0].Err)
+	pm.AssertSent(t, "orders", 1)
+	pm.AssertSentWithKey(t, "users", "alice", 0)
+}
+
+func TestProducerMockDelays(t *testing.T) {
+	pm := NewProducerMock()
+	pm.DelaySendsWithKey("slow", "key", 50 * time.Millisecond)
+
+	start := time.Now()
+	assert.Nil(t, pm.Send("slow", []byte("key"), nil))
+	assert.True(t, time.Since(start) >= 50 * time.Millisecond)
+
+	start = time.Now()
+	assert.Nil(t, pm.Send("slow", []byte("other"), nil))
+	assert.True(t, time.Since(start) < 50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, pm.SendContext(ctx, "slow", []byte("key"), nil, nil))
+	pm.AssertSent(t, "slow", 2)
+}
+
+func TestProducerMockWaitForMessages(t *testing.T) {
+	pm := NewProducerMock()
+	go func () {
+		for i := 0; i < 3; i++ {
+			pm.Send("topic", nil, nil)
+		}
+	}()
+
+	assert.True(t, pm.WaitForMessages(3, time.Second))
+	assert.False(t, pm.WaitForMessages(4, 10 * time.Millisecond))
+}
\ No newline at end of file
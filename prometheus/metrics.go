@@ -0,0 +1,142 @@
+package prometheus
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Metrics struct {
+	gatherer prometheus.Gatherer
+	consumed *prometheus.CounterVec
+	handled *prometheus.CounterVec
+	failed *prometheus.CounterVec
+	retries *prometheus.CounterVec
+	handlerLatency *prometheus.HistogramVec
+	commitLatency *prometheus.HistogramVec
+	commitFailures *prometheus.CounterVec
+	sendLatency *prometheus.HistogramVec
+	sendErrors *prometheus.CounterVec
+	rebalances *prometheus.CounterVec
+	kvLatency *prometheus.HistogramVec
+	kvErrors *prometheus.CounterVec
+	restarts *prometheus.CounterVec
+	cacheLookups *prometheus.CounterVec
+}
+
+func partitionLabel(partition int64) string {
+	return strconv.FormatInt(partition, 10)
+}
+
+func (m *Metrics) MessageConsumed(topic string, partition int64) {
+	m.consumed.WithLabelValues(topic, partitionLabel(partition)).Inc()
+}
+
+func (m *Metrics) MessageHandled(topic string, partition int64, duration time.Duration) {
+	m.handled.WithLabelValues(topic, partitionLabel(partition)).Inc()
+	m.handlerLatency.WithLabelValues(topic).Observe(duration.Seconds())
+}
+
+func (m *Metrics) MessageFailed(topic string, partition int64) {
+	m.failed.WithLabelValues(topic, partitionLabel(partition)).Inc()
+}
+
+func (m *Metrics) HandlerRetried(topic string, partition int64) {
+	m.retries.WithLabelValues(topic, partitionLabel(partition)).Inc()
+}
+
+func (m *Metrics) Committed(topic string, partition int64, duration time.Duration) {
+	m.commitLatency.WithLabelValues(topic).Observe(duration.Seconds())
+}
+
+func (m *Metrics) CommitFailed(topic string, partition int64) {
+	m.commitFailures.WithLabelValues(topic, partitionLabel(partition)).Inc()
+}
+
+func (m *Metrics) ProducerSent(topic string, duration time.Duration, err error) {
+	m.sendLatency.WithLabelValues(topic).Observe(duration.Seconds())
+	if err != nil {
+		m.sendErrors.WithLabelValues(topic).Inc()
+	}
+}
+
+func (m *Metrics) PartitionAssigned(topic string, partition int64) {
+	m.rebalances.WithLabelValues(topic, "assigned").Inc()
+}
+
+func (m *Metrics) PartitionRevoked(topic string, partition int64) {
+	m.rebalances.WithLabelValues(topic, "revoked").Inc()
+}
+
+func (m *Metrics) KVStoreOperation(operation string, duration time.Duration, err error) {
+	m.kvLatency.WithLabelValues(operation).Observe(duration.Seconds())
+	if err != nil {
+		m.kvErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (m *Metrics) RunnableRestarted(supervisor string, name string) {
+	m.restarts.WithLabelValues(supervisor, name).Inc()
+}
+
+func (m *Metrics) KVStoreCacheLookup(operation string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(operation, result).Inc()
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
+
+func NewMetrics(namespace string, registry *prometheus.Registry) *Metrics {
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if registry != nil {
+		registerer = registry
+		gatherer = registry
+	}
+
+	counter := func (name string, help string, labels ...string) *prometheus.CounterVec {
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name: name,
+			Help: help,
+		}, labels)
+		registerer.MustRegister(c)
+		return c
+	}
+
+	histogram := func (name string, help string, labels ...string) *prometheus.HistogramVec {
+		h := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name: name,
+			Help: help,
+			Buckets: prometheus.DefBuckets,
+		}, labels)
+		registerer.MustRegister(h)
+		return h
+	}
+
+	return &Metrics{
+		gatherer: gatherer,
+		consumed: counter("messages_consumed_total", "Messages received from the consumer", "topic", "partition"),
+		handled: counter("messages_handled_total", "Handler invocations", "topic", "partition"),
+		failed: counter("messages_failed_total", "Messages that failed decoding or whose handler returned an error", "topic", "partition"),
+		retries: counter("handler_retries_total", "Handler invocations repeated because the handler did not move on", "topic", "partition"),
+		handlerLatency: histogram("handler_duration_seconds", "Handler latency", "topic"),
+		commitLatency: histogram("commit_duration_seconds", "Offset commit latency", "topic"),
+		commitFailures: counter("commit_failures_total", "Failed offset commits", "topic", "partition"),
+		sendLatency: histogram("producer_send_duration_seconds", "Producer send latency", "topic"),
+		sendErrors: counter("producer_send_errors_total", "Failed producer sends", "topic"),
+		rebalances: counter("partition_rebalances_total", "Partition assignments and revocations", "topic", "type"),
+		kvLatency: histogram("kv_store_operation_duration_seconds", "Key-value store operation latency", "operation"),
+		kvErrors: counter("kv_store_operation_errors_total", "Failed key-value store operations", "operation"),
+		restarts: counter("supervisor_restarts_total", "Runnables restarted by a supervisor", "supervisor", "name"),
+		cacheLookups: counter("kv_store_cache_lookups_total", "Cached key-value store lookups", "operation", "result"),
+	}
+}
\ No newline at end of file
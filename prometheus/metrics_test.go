@@ -0,0 +1,55 @@
+package prometheus
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, 200, rec.Code)
+	body, err := ioutil.ReadAll(rec.Body)
+	assert.Nil(t, err)
+	return string(body)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	m := NewMetrics("app", prometheus.NewRegistry())
+	m.MessageConsumed("orders", 3)
+	m.MessageConsumed("orders", 3)
+	m.MessageHandled("orders", 3, 10 * time.Millisecond)
+	m.CommitFailed("orders", 1)
+	m.ProducerSent("events", time.Millisecond, errors.New("failed"))
+	m.PartitionRevoked("orders", 0)
+	m.KVStoreOperation("get", time.Millisecond, nil)
+	m.RunnableRestarted("root", "worker")
+	m.KVStoreCacheLookup("get", true)
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `app_messages_consumed_total{partition="3",topic="orders"} 2`)
+	assert.Contains(t, out, `app_messages_handled_total{partition="3",topic="orders"} 1`)
+	assert.Contains(t, out, `app_handler_duration_seconds_count{topic="orders"} 1`)
+	assert.Contains(t, out, `app_commit_failures_total{partition="1",topic="orders"} 1`)
+	assert.Contains(t, out, `app_producer_send_duration_seconds_count{topic="events"} 1`)
+	assert.Contains(t, out, `app_producer_send_errors_total{topic="events"} 1`)
+	assert.Contains(t, out, `app_partition_rebalances_total{topic="orders",type="revoked"} 1`)
+	assert.Contains(t, out, `app_kv_store_operation_duration_seconds_count{operation="get"} 1`)
+	assert.NotContains(t, out, `app_kv_store_operation_errors_total{operation="get"}`)
+	assert.Contains(t, out, `app_supervisor_restarts_total{name="worker",supervisor="root"} 1`)
+	assert.Contains(t, out, `app_kv_store_cache_lookups_total{operation="get",result="hit"} 1`)
+}
+
+func TestMetricsPrivateRegistries(t *testing.T) {
+	first := NewMetrics("app", prometheus.NewRegistry())
+	second := NewMetrics("app", prometheus.NewRegistry())
+	first.MessageFailed("orders", 0)
+
+	assert.Contains(t, scrape(t, first), `app_messages_failed_total{partition="0",topic="orders"} 1`)
+	assert.NotContains(t, scrape(t, second), `app_messages_failed_total{`)
+}
\ No newline at end of file
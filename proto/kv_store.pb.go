@@ -12,6 +12,9 @@ It has these top-level messages:
 	StringResponse
 	HashFieldRequest
 	HashResponse
+	ScanRequest
+	HashScanRequest
+	ScanEntry
 */
 package proto
 
@@ -107,11 +110,94 @@ func (m *HashResponse) GetValue() map[string]string {
 	return nil
 }
 
+type ScanRequest struct {
+	Pattern string `protobuf:"bytes,1,opt,name=pattern" json:"pattern,omitempty"`
+	Count   int64  `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *ScanRequest) Reset()                    { *m = ScanRequest{} }
+func (m *ScanRequest) String() string            { return proto1.CompactTextString(m) }
+func (*ScanRequest) ProtoMessage()               {}
+func (*ScanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *ScanRequest) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+func (m *ScanRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type HashScanRequest struct {
+	Key     string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Pattern string `protobuf:"bytes,2,opt,name=pattern" json:"pattern,omitempty"`
+	Count   int64  `protobuf:"varint,3,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *HashScanRequest) Reset()                    { *m = HashScanRequest{} }
+func (m *HashScanRequest) String() string            { return proto1.CompactTextString(m) }
+func (*HashScanRequest) ProtoMessage()               {}
+func (*HashScanRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *HashScanRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *HashScanRequest) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
+func (m *HashScanRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type ScanEntry struct {
+	Key   string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *ScanEntry) Reset()                    { *m = ScanEntry{} }
+func (m *ScanEntry) String() string            { return proto1.CompactTextString(m) }
+func (*ScanEntry) ProtoMessage()               {}
+func (*ScanEntry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *ScanEntry) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ScanEntry) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
 func init() {
 	proto1.RegisterType((*StringRequest)(nil), "turing.StringRequest")
 	proto1.RegisterType((*StringResponse)(nil), "turing.StringResponse")
 	proto1.RegisterType((*HashFieldRequest)(nil), "turing.HashFieldRequest")
 	proto1.RegisterType((*HashResponse)(nil), "turing.HashResponse")
+	proto1.RegisterType((*ScanRequest)(nil), "turing.ScanRequest")
+	proto1.RegisterType((*HashScanRequest)(nil), "turing.HashScanRequest")
+	proto1.RegisterType((*ScanEntry)(nil), "turing.ScanEntry")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -128,6 +214,8 @@ type KVStoreClient interface {
 	Get(ctx context.Context, in *StringRequest, opts ...grpc.CallOption) (*StringResponse, error)
 	HashGet(ctx context.Context, in *HashFieldRequest, opts ...grpc.CallOption) (*StringResponse, error)
 	HashGetAll(ctx context.Context, in *StringRequest, opts ...grpc.CallOption) (*HashResponse, error)
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (KVStore_ScanClient, error)
+	HashScan(ctx context.Context, in *HashScanRequest, opts ...grpc.CallOption) (KVStore_HashScanClient, error)
 }
 
 type kVStoreClient struct {
@@ -165,12 +253,78 @@ func (c *kVStoreClient) HashGetAll(ctx context.Context, in *StringRequest, opts
 	return out, nil
 }
 
+func (c *kVStoreClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (KVStore_ScanClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_KVStore_serviceDesc.Streams[0], c.cc, "/turing.KVStore/Scan", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kVStoreScanClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KVStore_ScanClient interface {
+	Recv() (*ScanEntry, error)
+	grpc.ClientStream
+}
+
+type kVStoreScanClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVStoreScanClient) Recv() (*ScanEntry, error) {
+	m := new(ScanEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *kVStoreClient) HashScan(ctx context.Context, in *HashScanRequest, opts ...grpc.CallOption) (KVStore_HashScanClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_KVStore_serviceDesc.Streams[1], c.cc, "/turing.KVStore/HashScan", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kVStoreHashScanClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type KVStore_HashScanClient interface {
+	Recv() (*ScanEntry, error)
+	grpc.ClientStream
+}
+
+type kVStoreHashScanClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVStoreHashScanClient) Recv() (*ScanEntry, error) {
+	m := new(ScanEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for KVStore service
 
 type KVStoreServer interface {
 	Get(context.Context, *StringRequest) (*StringResponse, error)
 	HashGet(context.Context, *HashFieldRequest) (*StringResponse, error)
 	HashGetAll(context.Context, *StringRequest) (*HashResponse, error)
+	Scan(*ScanRequest, KVStore_ScanServer) error
+	HashScan(*HashScanRequest, KVStore_HashScanServer) error
 }
 
 func RegisterKVStoreServer(s *grpc.Server, srv KVStoreServer) {
@@ -231,6 +385,48 @@ func _KVStore_HashGetAll_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KVStore_Scan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVStoreServer).Scan(m, &kVStoreScanServer{stream})
+}
+
+type KVStore_ScanServer interface {
+	Send(*ScanEntry) error
+	grpc.ServerStream
+}
+
+type kVStoreScanServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVStoreScanServer) Send(m *ScanEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _KVStore_HashScan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HashScanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVStoreServer).HashScan(m, &kVStoreHashScanServer{stream})
+}
+
+type KVStore_HashScanServer interface {
+	Send(*ScanEntry) error
+	grpc.ServerStream
+}
+
+type kVStoreHashScanServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVStoreHashScanServer) Send(m *ScanEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _KVStore_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "turing.KVStore",
 	HandlerType: (*KVStoreServer)(nil),
@@ -248,30 +444,46 @@ var _KVStore_serviceDesc = grpc.ServiceDesc{
 			Handler:    _KVStore_HashGetAll_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Scan",
+			Handler:       _KVStore_Scan_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "HashScan",
+			Handler:       _KVStore_HashScan_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "kv_store.proto",
 }
 
 func init() { proto1.RegisterFile("kv_store.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 279 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0xcb, 0x2e, 0x8b, 0x2f,
-	0x2e, 0xc9, 0x2f, 0x4a, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2b, 0x29, 0x2d, 0xca,
-	0xcc, 0x4b, 0x57, 0x52, 0xe4, 0xe2, 0x0d, 0x2e, 0x01, 0xb1, 0x82, 0x52, 0x0b, 0x4b, 0x53, 0x8b,
-	0x4b, 0x84, 0x04, 0xb8, 0x98, 0xb3, 0x53, 0x2b, 0x25, 0x18, 0x15, 0x18, 0x35, 0x38, 0x83, 0x40,
-	0x4c, 0x25, 0x35, 0x2e, 0x3e, 0x98, 0x92, 0xe2, 0x82, 0xfc, 0xbc, 0xe2, 0x54, 0x21, 0x11, 0x2e,
-	0xd6, 0xb2, 0xc4, 0x9c, 0xd2, 0x54, 0xa8, 0x2a, 0x08, 0x47, 0xc9, 0x8a, 0x4b, 0xc0, 0x23, 0xb1,
-	0x38, 0xc3, 0x2d, 0x33, 0x35, 0x27, 0x05, 0xa7, 0x69, 0x20, 0xbd, 0x69, 0x20, 0x15, 0x12, 0x4c,
-	0x10, 0xbd, 0x60, 0x8e, 0x52, 0x3d, 0x17, 0x0f, 0x48, 0x2f, 0xdc, 0x06, 0x53, 0x84, 0x0d, 0xcc,
-	0x1a, 0xdc, 0x46, 0xf2, 0x7a, 0x10, 0xe7, 0xea, 0x21, 0x2b, 0xd2, 0x0b, 0x03, 0xa9, 0x70, 0xcd,
-	0x2b, 0x29, 0xaa, 0x84, 0x3a, 0x41, 0xca, 0x82, 0x8b, 0x0b, 0x21, 0x88, 0xdd, 0x72, 0x88, 0xb1,
-	0x4c, 0x48, 0x0e, 0xb7, 0x62, 0xb2, 0x60, 0x34, 0xda, 0xcb, 0xc8, 0xc5, 0xee, 0x1d, 0x16, 0x0c,
-	0x0a, 0x21, 0x21, 0x33, 0x2e, 0x66, 0xf7, 0xd4, 0x12, 0x21, 0x51, 0x98, 0xa5, 0x28, 0x01, 0x24,
-	0x25, 0x86, 0x2e, 0x0c, 0x71, 0x8d, 0x12, 0x83, 0x90, 0x2d, 0x17, 0x3b, 0xc8, 0x7d, 0x20, 0xbd,
-	0x12, 0xc8, 0x0e, 0x46, 0x0e, 0x11, 0x3c, 0xda, 0xad, 0xb9, 0xb8, 0xa0, 0xda, 0x1d, 0x73, 0x72,
-	0x70, 0xd9, 0x2e, 0x82, 0x2d, 0x24, 0x94, 0x18, 0x9c, 0x14, 0xa3, 0xe4, 0xd3, 0x33, 0x4b, 0x32,
-	0x4a, 0x93, 0xf4, 0x92, 0xf3, 0x73, 0xf5, 0x13, 0x8b, 0x52, 0x73, 0x72, 0x52, 0x8b, 0xf4, 0x21,
-	0x6a, 0xf5, 0xc1, 0x51, 0x9e, 0xc4, 0x06, 0xa6, 0x8c, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0xbc,
-	0xad, 0x26, 0x4a, 0x0b, 0x02, 0x00, 0x00,
-}
+	// 366 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x53, 0x4f, 0x4f, 0xfa, 0x40,
+	0x10, 0xa5, 0xed, 0xef, 0x07, 0x32, 0x28, 0xe2, 0x8a, 0xda, 0x70, 0x41, 0xf6, 0x60, 0x38, 0x15,
+	0x03, 0xd1, 0x10, 0x0c, 0x07, 0x4d, 0xfc, 0x93, 0x78, 0xa3, 0x09, 0x07, 0x2f, 0xa6, 0xe0, 0x0a,
+	0x84, 0xda, 0xc5, 0xed, 0x96, 0x84, 0x93, 0x9f, 0xc8, 0xef, 0x68, 0xa6, 0xdb, 0xc2, 0xaa, 0xa0,
+	0xf1, 0xd4, 0x9d, 0xc9, 0x7b, 0x6f, 0xde, 0xbc, 0x49, 0xa1, 0x38, 0x9d, 0x3f, 0x86, 0x92, 0x0b,
+	0xe6, 0xcc, 0x04, 0x97, 0x9c, 0x64, 0x65, 0x24, 0x26, 0xc1, 0x88, 0xd6, 0x60, 0xc7, 0x95, 0xf8,
+	0xea, 0xb1, 0xd7, 0x88, 0x85, 0x92, 0x94, 0xc0, 0x9a, 0xb2, 0x85, 0x6d, 0x1c, 0x1b, 0xf5, 0x7c,
+	0x0f, 0x9f, 0xf4, 0x04, 0x8a, 0x29, 0x24, 0x9c, 0xf1, 0x20, 0x64, 0xa4, 0x0c, 0xff, 0xe7, 0x9e,
+	0x1f, 0xb1, 0x04, 0xa5, 0x0a, 0xda, 0x81, 0xd2, 0x9d, 0x17, 0x8e, 0x6f, 0x26, 0xcc, 0x7f, 0xda,
+	0xa8, 0x86, 0xdc, 0x67, 0x44, 0xd8, 0xa6, 0xe2, 0xc6, 0x05, 0x7d, 0x83, 0x6d, 0xe4, 0x2e, 0x27,
+	0x9c, 0xad, 0x26, 0x58, 0xf5, 0x42, 0xb3, 0xea, 0x28, 0xbb, 0x8e, 0x0e, 0x72, 0xfa, 0x88, 0xb8,
+	0x0e, 0xa4, 0x58, 0x24, 0x16, 0x2a, 0x6d, 0x80, 0x55, 0x73, 0xfd, 0x70, 0x25, 0x6b, 0x6a, 0xc6,
+	0x3b, 0x66, 0xdb, 0xa0, 0x5d, 0x28, 0xb8, 0x43, 0x2f, 0x48, 0x7d, 0xdb, 0x90, 0x9b, 0x79, 0x52,
+	0x32, 0x11, 0x24, 0xf4, 0xb4, 0x44, 0x89, 0x21, 0x8f, 0x02, 0x19, 0x4b, 0x58, 0x3d, 0x55, 0x50,
+	0x17, 0x76, 0xd1, 0x9a, 0x2e, 0xf1, 0x7d, 0xba, 0x26, 0x6a, 0x6e, 0x10, 0xb5, 0x74, 0xd1, 0x16,
+	0xe4, 0x51, 0xf0, 0x4f, 0xcb, 0x34, 0xdf, 0x4d, 0xc8, 0xdd, 0xf7, 0x5d, 0x3c, 0x35, 0x39, 0x07,
+	0xeb, 0x96, 0x49, 0x72, 0x90, 0xa6, 0xf7, 0xe9, 0xd2, 0x95, 0xc3, 0xaf, 0x6d, 0x15, 0x2b, 0xcd,
+	0x90, 0x2e, 0xe4, 0x70, 0x1b, 0xe4, 0xda, 0x7a, 0xf2, 0xfa, 0x69, 0x7f, 0xa0, 0x5f, 0x00, 0x24,
+	0xf4, 0x4b, 0xdf, 0xdf, 0x34, 0xbd, 0xbc, 0xee, 0xa4, 0x34, 0x43, 0x9a, 0xf0, 0x0f, 0x97, 0x26,
+	0xfb, 0x4b, 0xda, 0x2a, 0xd3, 0xca, 0x9e, 0xde, 0x8c, 0x73, 0xa1, 0x99, 0x53, 0x83, 0x74, 0x60,
+	0x2b, 0x4d, 0x9f, 0x1c, 0xe9, 0xba, 0xbf, 0x73, 0xaf, 0x6a, 0x0f, 0xd5, 0xd1, 0x44, 0x8e, 0xa3,
+	0x81, 0x33, 0xe4, 0x2f, 0x0d, 0x4f, 0x30, 0xdf, 0x67, 0xa2, 0xa1, 0xa0, 0x8d, 0xf8, 0x5f, 0x19,
+	0x64, 0xe3, 0x4f, 0xeb, 0x63, 0x00, 0x02, 0x5c, 0xa0, 0x56, 0x44, 0x03, 0x00, 0x00,
+}
\ No newline at end of file
@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"regexp"
+	"strings"
+)
+
+type KeyGateway func (key string) (string, bool)
+
+func PassGateway() KeyGateway {
+	return func (key string) (string, bool) {
+		return key, true
+	}
+}
+
+func PrefixGateway(prefix string) KeyGateway {
+	return func (key string) (string, bool) {
+		return prefix + key, true
+	}
+}
+
+func AllowGateway(patterns ...*regexp.Regexp) KeyGateway {
+	return func (key string) (string, bool) {
+		for _, p := range patterns {
+			if p.MatchString(key) {
+				return key, true
+			}
+		}
+		return "", false
+	}
+}
+
+func DenyGateway(patterns ...*regexp.Regexp) KeyGateway {
+	return func (key string) (string, bool) {
+		for _, p := range patterns {
+			if p.MatchString(key) {
+				return "", false
+			}
+		}
+		return key, true
+	}
+}
+
+func TenantGateway(separator string, tenants map[string]KeyGateway) KeyGateway {
+	return func (key string) (string, bool) {
+		i := strings.Index(key, separator)
+		if i < 0 {
+			return "", false
+		}
+
+		gateway, ok := tenants[key[:i]]
+		if !ok {
+			return "", false
+		}
+		return gateway(key[i + len(separator):])
+	}
+}
+
+func ChainGateways(gateways ...KeyGateway) KeyGateway {
+	return func (key string) (string, bool) {
+		for _, g := range gateways {
+			var ok bool
+			key, ok = g(key)
+			if !ok {
+				return "", false
+			}
+		}
+		return key, true
+	}
+}
\ No newline at end of file
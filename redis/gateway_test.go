@@ -0,0 +1,168 @@
+package redis
+
+import (
+	"regexp"
+	"testing"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/areller/turing"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatewayBuilders(t *testing.T) {
+	gateway := ChainGateways(
+		DenyGateway(regexp.MustCompile("^secret")),
+		AllowGateway(regexp.MustCompile("^user_"), regexp.MustCompile("^order_")),
+		PrefixGateway("app:"),
+	)
+
+	key, ok := gateway("user_1")
+	assert.True(t, ok)
+	assert.Equal(t, "app:user_1", key)
+
+	_, ok = gateway("secret_user_1")
+	assert.False(t, ok)
+
+	_, ok = gateway("other")
+	assert.False(t, ok)
+
+	tenants := TenantGateway("/", map[string]KeyGateway{
+		"a": PrefixGateway("tenant_a:"),
+		"b": ChainGateways(AllowGateway(regexp.MustCompile("^public")), PrefixGateway("tenant_b:")),
+	})
+
+	key, ok = tenants("a/key")
+	assert.True(t, ok)
+	assert.Equal(t, "tenant_a:key", key)
+
+	key, ok = tenants("b/public_key")
+	assert.True(t, ok)
+	assert.Equal(t, "tenant_b:public_key", key)
+
+	_, ok = tenants("b/private_key")
+	assert.False(t, ok)
+
+	_, ok = tenants("c/key")
+	assert.False(t, ok)
+
+	_, ok = tenants("key")
+	assert.False(t, ok)
+}
+
+func TestGatewayAdapter(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+	defer client.Close()
+
+	store := AdaptToKVStore(client, ChainGateways(
+		DenyGateway(regexp.MustCompile("^blocked")),
+		PrefixGateway("app:"),
+	))
+
+	assert.Nil(t, store.Set("a", "1"))
+	assert.True(t, server.Exists("app:a"))
+
+	assert.Equal(t, turing.KeyNotExistsError, store.Set("blocked", "1"))
+	n, err := store.Delete("blocked", "blocked_too")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+	n, err = store.Exists("blocked")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	readOnly := store.ReadOnly()
+	val, err := readOnly.Get("a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", val)
+
+	assert.Equal(t, turing.ReadOnlyError, readOnly.Set("a", "2"))
+	_, err = readOnly.Delete("a")
+	assert.Equal(t, turing.ReadOnlyError, err)
+	_, err = readOnly.Incr("a")
+	assert.Equal(t, turing.ReadOnlyError, err)
+
+	pipe := readOnly.Pipeline()
+	get := pipe.Get("a")
+	set := pipe.Set("a", "3")
+	assert.Equal(t, turing.ReadOnlyError, pipe.Exec())
+	assert.Equal(t, "1", get.Value)
+	assert.Equal(t, turing.ReadOnlyError, set.Err)
+
+	val, _ = store.Get("a")
+	assert.Equal(t, "1", val)
+
+	// A rejected command fails the whole transaction.
+	assert.Nil(t, store.Set("b", "1"))
+	pipe = readOnly.TxPipeline()
+	get = pipe.Get("a")
+	incr := pipe.IncrBy("b", 1)
+	set = pipe.Set("a", "3")
+	assert.Equal(t, turing.ReadOnlyError, pipe.Exec())
+	assert.Equal(t, turing.ReadOnlyError, get.Err)
+	assert.Equal(t, turing.ReadOnlyError, incr.Err)
+	assert.Equal(t, turing.ReadOnlyError, set.Err)
+
+	val, _ = store.Get("a")
+	assert.Equal(t, "1", val)
+	val, _ = store.Get("b")
+	assert.Equal(t, "1", val)
+}
+
+func TestGatewayScan(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+	defer client.Close()
+
+	store := AdaptToKVStore(client, ChainGateways(
+		DenyGateway(regexp.MustCompile("^blocked")),
+		PrefixGateway("app:"),
+	), WithReverseGateway(PrefixReverseGateway("app:")))
+
+	server.Set("other:a", "1")
+	server.Set("app:blocked", "1")
+	assert.Nil(t, store.Set("a", "1"))
+	assert.Nil(t, store.Set("b", "1"))
+
+	var keys []string
+	it := store.Scan("*", 10)
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	assert.Nil(t, it.Err())
+	assert.ElementsMatch(t, []string{ "a", "b" }, keys)
+
+	it = store.ReadOnly().Scan("a", 10)
+	assert.True(t, it.Next())
+	assert.Equal(t, "a", it.Key())
+	assert.False(t, it.Next())
+
+	irreversible := AdaptToKVStore(client, PrefixGateway("app:"))
+	it = irreversible.Scan("*", 10)
+	assert.False(t, it.Next())
+	assert.Equal(t, turing.IrreversibleGatewayError, it.Err())
+	_, err = irreversible.WatchPattern("*")
+	assert.Equal(t, turing.IrreversibleGatewayError, err)
+
+	filtered := AdaptToKVStore(client, DenyGateway(regexp.MustCompile("^app:")), WithReverseGateway(PassReverseGateway()))
+	keys = nil
+	it = filtered.Scan("*", 10)
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	assert.Nil(t, it.Err())
+	assert.ElementsMatch(t, []string{ "other:a" }, keys)
+}
\ No newline at end of file
@@ -1,15 +1,34 @@
 package redis
 
 import (
+	"context"
+	"strconv"
 	"time"
 	"strings"
 	"github.com/go-redis/redis"
 	"github.com/areller/turing"
 )
 
+var renewLeaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
 type redisAdapter struct {
-	client *redis.Client
+	client redis.UniversalClient
+	ctx context.Context
 	keyGateway func (string) (string, bool)
+	reverseGateway ReverseGateway
+	readOnly bool
 }
 
 func (ra *redisAdapter) convertError(err error) error {
@@ -17,7 +36,9 @@ func (ra *redisAdapter) convertError(err error) error {
 		return nil
 	}
 
-	if err == redis.Nil {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	} else if err == redis.Nil {
 		return turing.KeyNotExistsError
 	} else if strings.Contains(err.Error(), "WRONGTYPE") {
 		return turing.WrongTypeError
@@ -30,6 +51,29 @@ func (ra *redisAdapter) convertError(err error) error {
 	return nil
 }
 
+func (ra *redisAdapter) isCluster() bool {
+	_, ok := ra.client.(*redis.ClusterClient)
+	return ok
+}
+
+func (ra *redisAdapter) perKey(keys []string, cmd func (pipe redis.Pipeliner, key string) *redis.IntCmd) (int64, error) {
+	pipe := ra.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, k := range keys {
+		cmds[i] = cmd(pipe, k)
+	}
+
+	if _, err := pipe.Exec(); err != nil {
+		return 0, err
+	}
+
+	var res int64
+	for _, c := range cmds {
+		res += c.Val()
+	}
+	return res, nil
+}
+
 func (ra *redisAdapter) filterKeys(keys []string) []string {
 	var newKeys []string
 	for _, k := range keys {
@@ -42,6 +86,14 @@ func (ra *redisAdapter) filterKeys(keys []string) []string {
 }
 
 func (ra *redisAdapter) Set(key string, value interface{}) error {
+	if err := ra.ctx.Err(); err != nil {
+		return err
+	}
+
+	if ra.readOnly {
+		return turing.ReadOnlyError
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return turing.KeyNotExistsError
@@ -51,7 +103,65 @@ func (ra *redisAdapter) Set(key string, value interface{}) error {
 	return ra.convertError(err)
 }
 
+func (ra *redisAdapter) SetNX(key string, value interface{}, expiry time.Duration) (bool, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if ra.readOnly {
+		return false, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return false, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.SetNX(key, value, expiry).Result()
+	return res, ra.convertError(err)
+}
+
+func (ra *redisAdapter) RenewLease(key string, token string, ttl time.Duration) (bool, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if ra.readOnly {
+		return false, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return false, turing.KeyNotExistsError
+	}
+
+	res, err := renewLeaseScript.Run(ra.client, []string{ key }, token, int64(ttl / time.Millisecond)).Int64()
+	return res == 1, ra.convertError(err)
+}
+
+func (ra *redisAdapter) ReleaseLease(key string, token string) (bool, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	if ra.readOnly {
+		return false, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return false, turing.KeyNotExistsError
+	}
+
+	res, err := releaseLeaseScript.Run(ra.client, []string{ key }, token).Int64()
+	return res == 1, ra.convertError(err)
+}
+
 func (ra *redisAdapter) Get(key string) (string, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return "", err
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return "", turing.KeyNotExistsError
@@ -63,20 +173,62 @@ func (ra *redisAdapter) Get(key string) (string, error) {
 }
 
 func (ra *redisAdapter) Delete(keys ...string) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
 	keys = ra.filterKeys(keys)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	if ra.isCluster() {
+		res, err := ra.perKey(keys, func (pipe redis.Pipeliner, key string) *redis.IntCmd {
+			return pipe.Del(key)
+		})
+		return int(res), ra.convertError(err)
+	}
+
 	res, err := ra.client.Del(keys...).Result()
 	err = ra.convertError(err)
 	return int(res), err
 }
 
 func (ra *redisAdapter) Exists(keys ...string) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
 	keys = ra.filterKeys(keys)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	if ra.isCluster() {
+		res, err := ra.perKey(keys, func (pipe redis.Pipeliner, key string) *redis.IntCmd {
+			return pipe.Exists(key)
+		})
+		return int(res), ra.convertError(err)
+	}
+
 	res, err := ra.client.Exists(keys...).Result()
 	err = ra.convertError(err)
 	return int(res), err
 }
 
 func (ra *redisAdapter) HSet(key string, field string, value interface{}) error {
+	if err := ra.ctx.Err(); err != nil {
+		return err
+	}
+
+	if ra.readOnly {
+		return turing.ReadOnlyError
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return turing.KeyNotExistsError
@@ -87,16 +239,34 @@ func (ra *redisAdapter) HSet(key string, field string, value interface{}) error
 }
 
 func (ra *redisAdapter) HSetMany(key string, kv map[string]interface{}) error {
+	if err := ra.ctx.Err(); err != nil {
+		return err
+	}
+
+	if ra.readOnly {
+		return turing.ReadOnlyError
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return turing.KeyNotExistsError
 	}
 
+	// HMSET refuses an empty field list, like the other stores we treat it as
+	// a no-op.
+	if len(kv) == 0 {
+		return nil
+	}
+
 	_, err := ra.client.HMSet(key, kv).Result()
 	return ra.convertError(err)
 }
 
 func (ra *redisAdapter) HGet(key string, field string) (string, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return "", err
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return "", turing.KeyNotExistsError
@@ -108,6 +278,10 @@ func (ra *redisAdapter) HGet(key string, field string) (string, error) {
 }
 
 func (ra *redisAdapter) HGetAll(key string) (map[string]string, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return nil, turing.KeyNotExistsError
@@ -119,6 +293,14 @@ func (ra *redisAdapter) HGetAll(key string) (map[string]string, error) {
 }
 
 func (ra *redisAdapter) HDelete(key string, fields ...string) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return 0, turing.KeyNotExistsError
@@ -130,24 +312,345 @@ func (ra *redisAdapter) HDelete(key string, fields ...string) (int, error) {
 }
 
 func (ra *redisAdapter) Expire(key string, expiry time.Duration) error {
+	if err := ra.ctx.Err(); err != nil {
+		return err
+	}
+
+	if ra.readOnly {
+		return turing.ReadOnlyError
+	}
+
 	key, ex := ra.keyGateway(key)
 	if !ex {
 		return turing.KeyNotExistsError
 	}
 
-	_, err := ra.client.Expire(key, expiry).Result()
+	ok, err := ra.client.Expire(key, expiry).Result()
+	if err == nil && !ok {
+		return turing.KeyNotExistsError
+	}
 	return ra.convertError(err)
 }
 
-func AdaptToKVStore(client *redis.Client, keyGateway func (key string) (string, bool)) *redisAdapter {
-	if keyGateway == nil {
-		keyGateway = func (key string) (string, bool) {
-			return key, true
+func (ra *redisAdapter) toInterfaces(values []string) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		res[i] = v
+	}
+	return res
+}
+
+func (ra *redisAdapter) Incr(key string) (int64, error) {
+	return ra.IncrBy(key, 1)
+}
+
+func (ra *redisAdapter) IncrBy(key string, value int64) (int64, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return 0, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.IncrBy(key, value).Result()
+	return res, ra.convertError(err)
+}
+
+func (ra *redisAdapter) HIncrBy(key string, field string, value int64) (int64, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return 0, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.HIncrBy(key, field, value).Result()
+	return res, ra.convertError(err)
+}
+
+func (ra *redisAdapter) SAdd(key string, members ...string) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return 0, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.SAdd(key, ra.toInterfaces(members)...).Result()
+	return int(res), ra.convertError(err)
+}
+
+func (ra *redisAdapter) SRem(key string, members ...string) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return 0, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.SRem(key, ra.toInterfaces(members)...).Result()
+	return int(res), ra.convertError(err)
+}
+
+func (ra *redisAdapter) SIsMember(key string, member string) (bool, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return false, err
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return false, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.SIsMember(key, member).Result()
+	return res, ra.convertError(err)
+}
+
+func (ra *redisAdapter) SMembers(key string) ([]string, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return nil, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.SMembers(key).Result()
+	return res, ra.convertError(err)
+}
+
+func (ra *redisAdapter) ZAdd(key string, members ...turing.ZMember) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return 0, turing.KeyNotExistsError
+	}
+
+	zs := make([]redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = redis.Z{
+			Score: m.Score,
+			Member: m.Member,
 		}
 	}
 
+	res, err := ra.client.ZAdd(key, zs...).Result()
+	return int(res), ra.convertError(err)
+}
+
+func (ra *redisAdapter) ZRangeByScore(key string, min float64, max float64) ([]turing.ZMember, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return nil, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.ZRangeByScoreWithScores(key, redis.ZRangeBy{
+		Min: strconv.FormatFloat(min, 'f', -1, 64),
+		Max: strconv.FormatFloat(max, 'f', -1, 64),
+	}).Result()
+	if err != nil {
+		return nil, ra.convertError(err)
+	}
+
+	members := make([]turing.ZMember, len(res))
+	for i, z := range res {
+		members[i] = turing.ZMember{
+			Member: z.Member.(string),
+			Score: z.Score,
+		}
+	}
+	return members, nil
+}
+
+func (ra *redisAdapter) ZRem(key string, members ...string) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return 0, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.ZRem(key, ra.toInterfaces(members)...).Result()
+	return int(res), ra.convertError(err)
+}
+
+func (ra *redisAdapter) LPush(key string, values ...string) (int, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if ra.readOnly {
+		return 0, turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return 0, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.LPush(key, ra.toInterfaces(values)...).Result()
+	return int(res), ra.convertError(err)
+}
+
+func (ra *redisAdapter) RPop(key string) (string, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return "", err
+	}
+
+	if ra.readOnly {
+		return "", turing.ReadOnlyError
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return "", turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.RPop(key).Result()
+	return res, ra.convertError(err)
+}
+
+func (ra *redisAdapter) LRange(key string, start int64, stop int64) ([]string, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return nil, turing.KeyNotExistsError
+	}
+
+	res, err := ra.client.LRange(key, start, stop).Result()
+	return res, ra.convertError(err)
+}
+
+func (ra *redisAdapter) Ping() error {
+	_, err := ra.client.Ping().Result()
+	return ra.convertError(err)
+}
+
+func (ra *redisAdapter) WithContext(ctx context.Context) turing.KVStore {
+	client := ra.client
+	switch c := client.(type) {
+	case *redis.Client:
+		client = c.WithContext(ctx)
+	case *redis.ClusterClient:
+		client = c.WithContext(ctx)
+	}
+
 	return &redisAdapter{
 		client: client,
+		ctx: ctx,
+		keyGateway: ra.keyGateway,
+		reverseGateway: ra.reverseGateway,
+		readOnly: ra.readOnly,
+	}
+}
+
+func (ra *redisAdapter) ReadOnly() *redisAdapter {
+	return &redisAdapter{
+		client: ra.client,
+		ctx: ra.ctx,
+		keyGateway: ra.keyGateway,
+		reverseGateway: ra.reverseGateway,
+		readOnly: true,
+	}
+}
+
+// splitAddrs splits a comma separated list of addresses, dropping blank
+// entries.
+func splitAddrs(addrs string) []string {
+	var split []string
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			split = append(split, addr)
+		}
+	}
+	return split
+}
+
+func UniversalOptionsFromTable(table turing.ConfigTable) *redis.UniversalOptions {
+	return &redis.UniversalOptions{
+		Addrs: splitAddrs(table.GetString("redis_addrs")),
+		MasterName: table.GetString("redis_master_name"),
+		Password: table.GetString("redis_password"),
+		DB: table.GetInt("redis_db"),
+		PoolSize: table.GetInt("redis_pool_size"),
+		ReadOnly: table.GetBool("redis_read_only"),
+		RouteByLatency: table.GetBool("redis_route_by_latency"),
+	}
+}
+
+type AdapterOption func (ra *redisAdapter)
+
+// WithReverseGateway sets the reverse of the adapter's key gateway, without it
+// Scan and WatchPattern fail with IrreversibleGatewayError unless there is no
+// key gateway at all.
+func WithReverseGateway(reverseGateway ReverseGateway) AdapterOption {
+	return func (ra *redisAdapter) {
+		ra.reverseGateway = reverseGateway
+	}
+}
+
+func NewKVStoreFromTable(table turing.ConfigTable, keyGateway func (key string) (string, bool), opts ...AdapterOption) *redisAdapter {
+	return AdaptToKVStore(redis.NewUniversalClient(UniversalOptionsFromTable(table)), keyGateway, opts...)
+}
+
+func AdaptToKVStore(client redis.UniversalClient, keyGateway func (key string) (string, bool), opts ...AdapterOption) *redisAdapter {
+	ra := &redisAdapter{
+		client: client,
+		ctx: context.Background(),
 		keyGateway: keyGateway,
 	}
+	if keyGateway == nil {
+		ra.keyGateway = PassGateway()
+		ra.reverseGateway = PassReverseGateway()
+	}
+
+	for _, opt := range opts {
+		opt(ra)
+	}
+	return ra
 }
\ No newline at end of file
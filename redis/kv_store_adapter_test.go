@@ -0,0 +1,107 @@
+package redis
+
+import (
+	"strconv"
+	"testing"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/areller/turing"
+	"github.com/areller/turing/kvtest"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisAdapter(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+	defer client.Close()
+
+	kvtest.Run(t, func () turing.KVStore {
+		server.FlushAll()
+		return AdaptToKVStore(client, nil)
+	}, server.FastForward)
+}
+
+type mapConfigTable map[string]string
+
+func (mct mapConfigTable) GetString(key string) string {
+	return mct[key]
+}
+
+func (mct mapConfigTable) GetBool(key string) bool {
+	return mct[key] == "true"
+}
+
+func (mct mapConfigTable) GetInt(key string) int {
+	n, _ := strconv.Atoi(mct[key])
+	return n
+}
+
+func (mct mapConfigTable) GetInt64(key string) int64 {
+	n, _ := strconv.ParseInt(mct[key], 10, 64)
+	return n
+}
+
+func (mct mapConfigTable) GetFloat64(key string) float64 {
+	f, _ := strconv.ParseFloat(mct[key], 64)
+	return f
+}
+
+func TestUniversalOptionsFromTableAddrs(t *testing.T) {
+	opts := UniversalOptionsFromTable(mapConfigTable{
+		"redis_addrs": " host1:6379, host2:6379,, ",
+	})
+	assert.Equal(t, []string{ "host1:6379", "host2:6379" }, opts.Addrs)
+
+	opts = UniversalOptionsFromTable(mapConfigTable{})
+	assert.Empty(t, opts.Addrs)
+}
+
+func TestRedisAdapterCluster(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs: []string{ server.Addr() },
+	})
+	defer client.Close()
+
+	kvtest.Run(t, func () turing.KVStore {
+		server.FlushAll()
+		return AdaptToKVStore(client, nil)
+	}, server.FastForward)
+}
+
+func TestNewKVStoreFromTable(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	store := NewKVStoreFromTable(mapConfigTable{
+		"redis_addrs": server.Addr(),
+		"redis_db": "0",
+	}, nil)
+	defer store.client.Close()
+
+	_, ok := store.client.(*redis.Client)
+	assert.True(t, ok)
+	assert.Nil(t, store.Ping())
+
+	options := UniversalOptionsFromTable(mapConfigTable{
+		"redis_addrs": "a:6379,b:6379,c:6379",
+		"redis_master_name": "mymaster",
+	})
+	assert.Equal(t, []string{ "a:6379", "b:6379", "c:6379" }, options.Addrs)
+	assert.Equal(t, "mymaster", options.MasterName)
+}
\ No newline at end of file
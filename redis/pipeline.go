@@ -0,0 +1,379 @@
+package redis
+
+import (
+	"time"
+	"github.com/go-redis/redis"
+	"github.com/areller/turing"
+)
+
+type redisPipeline struct {
+	ra *redisAdapter
+	pipe redis.Pipeliner
+	resolvers []func () (interface{}, error)
+	results []*turing.PipelineResult
+	err error
+	tx bool
+}
+
+func (rp *redisPipeline) queue(resolve func () (interface{}, error)) *turing.PipelineResult {
+	res := &turing.PipelineResult{}
+	rp.resolvers = append(rp.resolvers, resolve)
+	rp.results = append(rp.results, res)
+	return res
+}
+
+func (rp *redisPipeline) queuePerKey(keys []string, cmd func (keys ...string) *redis.IntCmd) *turing.PipelineResult {
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, k := range keys {
+		cmds[i] = cmd(k)
+	}
+
+	return rp.queue(func () (interface{}, error) {
+		res := 0
+		for _, c := range cmds {
+			n, err := c.Result()
+			if err != nil {
+				return 0, err
+			}
+			res += int(n)
+		}
+		return res, nil
+	})
+}
+
+func (rp *redisPipeline) rejected() *turing.PipelineResult {
+	rp.err = turing.ReadOnlyError
+	return &turing.PipelineResult{
+		Err: turing.ReadOnlyError,
+	}
+}
+
+func (rp *redisPipeline) filtered() *turing.PipelineResult {
+	return &turing.PipelineResult{
+		Err: turing.KeyNotExistsError,
+	}
+}
+
+func (rp *redisPipeline) Set(key string, value interface{}) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.Set(key, value, -1)
+	return rp.queue(func () (interface{}, error) {
+		return nil, cmd.Err()
+	})
+}
+
+func (rp *redisPipeline) Get(key string) *turing.PipelineResult {
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.Get(key)
+	return rp.queue(func () (interface{}, error) {
+		return cmd.Result()
+	})
+}
+
+func (rp *redisPipeline) Delete(keys ...string) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	keys = rp.ra.filterKeys(keys)
+	if len(keys) == 0 {
+		return &turing.PipelineResult{
+			Value: 0,
+		}
+	}
+
+	if rp.ra.isCluster() {
+		return rp.queuePerKey(keys, rp.pipe.Del)
+	}
+
+	cmd := rp.pipe.Del(keys...)
+	return rp.queue(func () (interface{}, error) {
+		res, err := cmd.Result()
+		return int(res), err
+	})
+}
+
+func (rp *redisPipeline) Exists(keys ...string) *turing.PipelineResult {
+	keys = rp.ra.filterKeys(keys)
+	if len(keys) == 0 {
+		return &turing.PipelineResult{
+			Value: 0,
+		}
+	}
+
+	if rp.ra.isCluster() {
+		return rp.queuePerKey(keys, rp.pipe.Exists)
+	}
+
+	cmd := rp.pipe.Exists(keys...)
+	return rp.queue(func () (interface{}, error) {
+		res, err := cmd.Result()
+		return int(res), err
+	})
+}
+
+func (rp *redisPipeline) HSet(key string, field string, value interface{}) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.HSet(key, field, value)
+	return rp.queue(func () (interface{}, error) {
+		return nil, cmd.Err()
+	})
+}
+
+func (rp *redisPipeline) HSetMany(key string, kv map[string]interface{}) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	if len(kv) == 0 {
+		return &turing.PipelineResult{}
+	}
+
+	cmd := rp.pipe.HMSet(key, kv)
+	return rp.queue(func () (interface{}, error) {
+		return nil, cmd.Err()
+	})
+}
+
+func (rp *redisPipeline) HGet(key string, field string) *turing.PipelineResult {
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.HGet(key, field)
+	return rp.queue(func () (interface{}, error) {
+		return cmd.Result()
+	})
+}
+
+func (rp *redisPipeline) HGetAll(key string) *turing.PipelineResult {
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.HGetAll(key)
+	return rp.queue(func () (interface{}, error) {
+		return cmd.Result()
+	})
+}
+
+func (rp *redisPipeline) HDelete(key string, fields ...string) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.HDel(key, fields...)
+	return rp.queue(func () (interface{}, error) {
+		res, err := cmd.Result()
+		return int(res), err
+	})
+}
+
+func (rp *redisPipeline) Expire(key string, expiry time.Duration) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.Expire(key, expiry)
+	return rp.queue(func () (interface{}, error) {
+		ok, err := cmd.Result()
+		if err == nil && !ok {
+			err = redis.Nil
+		}
+		return nil, err
+	})
+}
+
+func (rp *redisPipeline) IncrBy(key string, value int64) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.IncrBy(key, value)
+	return rp.queue(func () (interface{}, error) {
+		return cmd.Result()
+	})
+}
+
+func (rp *redisPipeline) HIncrBy(key string, field string, value int64) *turing.PipelineResult {
+	if rp.ra.readOnly {
+		return rp.rejected()
+	}
+
+	key, ex := rp.ra.keyGateway(key)
+	if !ex {
+		return rp.filtered()
+	}
+
+	cmd := rp.pipe.HIncrBy(key, field, value)
+	return rp.queue(func () (interface{}, error) {
+		return cmd.Result()
+	})
+}
+
+func (rp *redisPipeline) Exec() error {
+	resolvers, results, rejectedErr := rp.resolvers, rp.results, rp.err
+	rp.resolvers, rp.results, rp.err = nil, nil, nil
+
+	if err := rp.ra.ctx.Err(); err != nil {
+		rp.pipe.Discard()
+		for _, res := range results {
+			res.Err = err
+		}
+		return err
+	}
+
+	// A transaction fails as a whole, none of its commands may run when one
+	// of them was rejected.
+	if rp.tx && rejectedErr != nil {
+		rp.pipe.Discard()
+		for _, res := range results {
+			res.Err = rejectedErr
+		}
+		return rejectedErr
+	}
+
+	_, err := rp.pipe.Exec()
+	if err == redis.TxFailedErr {
+		for _, res := range results {
+			res.Err = turing.TxFailedError
+		}
+		return turing.TxFailedError
+	}
+
+	firstErr := rejectedErr
+	for i, resolve := range resolvers {
+		value, err := resolve()
+		results[i].Value, results[i].Err = value, rp.ra.convertError(err)
+		if results[i].Err != nil && results[i].Err != turing.KeyNotExistsError && firstErr == nil {
+			firstErr = results[i].Err
+		}
+	}
+	return firstErr
+}
+
+type redisTx struct {
+	ra *redisAdapter
+	tx *redis.Tx
+}
+
+func (rt *redisTx) Get(key string) (string, error) {
+	key, ex := rt.ra.keyGateway(key)
+	if !ex {
+		return "", turing.KeyNotExistsError
+	}
+
+	res, err := rt.tx.Get(key).Result()
+	return res, rt.ra.convertError(err)
+}
+
+func (rt *redisTx) Exists(keys ...string) (int, error) {
+	keys = rt.ra.filterKeys(keys)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	res, err := rt.tx.Exists(keys...).Result()
+	return int(res), rt.ra.convertError(err)
+}
+
+func (rt *redisTx) HGet(key string, field string) (string, error) {
+	key, ex := rt.ra.keyGateway(key)
+	if !ex {
+		return "", turing.KeyNotExistsError
+	}
+
+	res, err := rt.tx.HGet(key, field).Result()
+	return res, rt.ra.convertError(err)
+}
+
+func (rt *redisTx) HGetAll(key string) (map[string]string, error) {
+	key, ex := rt.ra.keyGateway(key)
+	if !ex {
+		return nil, turing.KeyNotExistsError
+	}
+
+	res, err := rt.tx.HGetAll(key).Result()
+	return res, rt.ra.convertError(err)
+}
+
+func (rt *redisTx) TxPipeline() turing.Pipeline {
+	return &redisPipeline{
+		ra: rt.ra,
+		pipe: rt.tx.TxPipeline(),
+	}
+}
+
+func (ra *redisAdapter) Pipeline() turing.Pipeline {
+	return &redisPipeline{
+		ra: ra,
+		pipe: ra.client.Pipeline(),
+	}
+}
+
+func (ra *redisAdapter) TxPipeline() turing.Pipeline {
+	return &redisPipeline{
+		ra: ra,
+		pipe: ra.client.TxPipeline(),
+		tx: true,
+	}
+}
+
+func (ra *redisAdapter) Watch(fn func (tx turing.Tx) error, keys ...string) error {
+	if err := ra.ctx.Err(); err != nil {
+		return err
+	}
+
+	err := ra.client.Watch(func (tx *redis.Tx) error {
+		return fn(&redisTx{
+			ra: ra,
+			tx: tx,
+		})
+	}, ra.filterKeys(keys)...)
+	if err == redis.TxFailedErr {
+		return turing.TxFailedError
+	}
+	return err
+}
\ No newline at end of file
@@ -0,0 +1,183 @@
+package redis
+
+import (
+	"strings"
+	"sync"
+	"github.com/go-redis/redis"
+	"github.com/areller/turing"
+)
+
+// ReverseGateway maps a raw redis key back to the key seen through a
+// KeyGateway. Scan and WatchPattern need one whenever the gateway rewrites
+// keys, see WithReverseGateway.
+type ReverseGateway func (rawKey string) (string, bool)
+
+// PassReverseGateway is the reverse of gateways that only filter keys, such
+// as AllowGateway and DenyGateway.
+func PassReverseGateway() ReverseGateway {
+	return func (rawKey string) (string, bool) {
+		return rawKey, true
+	}
+}
+
+func PrefixReverseGateway(prefix string) ReverseGateway {
+	return func (rawKey string) (string, bool) {
+		if !strings.HasPrefix(rawKey, prefix) {
+			return "", false
+		}
+		return strings.TrimPrefix(rawKey, prefix), true
+	}
+}
+
+type redisScanIterator struct {
+	adapter *redisAdapter
+	pattern string
+	it *redis.ScanIterator
+	key string
+	err error
+}
+
+func (rsi *redisScanIterator) Next() bool {
+	for rsi.err == nil && rsi.it.Next() {
+		if key, ok := rsi.adapter.userKey(rsi.pattern, rsi.it.Val()); ok {
+			rsi.key = key
+			return true
+		}
+	}
+
+	if rsi.err == nil {
+		rsi.err = rsi.adapter.convertError(rsi.it.Err())
+	}
+	return false
+}
+
+func (rsi *redisScanIterator) Key() string {
+	return rsi.key
+}
+
+func (rsi *redisScanIterator) Value() string {
+	return ""
+}
+
+func (rsi *redisScanIterator) Err() error {
+	return rsi.err
+}
+
+type redisHashScanIterator struct {
+	adapter *redisAdapter
+	it *redis.ScanIterator
+	field string
+	value string
+	err error
+}
+
+func (rhsi *redisHashScanIterator) Next() bool {
+	if rhsi.err == nil && rhsi.it.Next() {
+		rhsi.field = rhsi.it.Val()
+		if rhsi.it.Next() {
+			rhsi.value = rhsi.it.Val()
+			return true
+		}
+	}
+
+	if rhsi.err == nil {
+		rhsi.err = rhsi.adapter.convertError(rhsi.it.Err())
+	}
+	return false
+}
+
+func (rhsi *redisHashScanIterator) Key() string {
+	return rhsi.field
+}
+
+func (rhsi *redisHashScanIterator) Value() string {
+	return rhsi.value
+}
+
+func (rhsi *redisHashScanIterator) Err() error {
+	return rhsi.err
+}
+
+func (ra *redisAdapter) rawPattern(pattern string) string {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	if raw, ok := ra.keyGateway(pattern); ok {
+		return raw
+	}
+	return pattern
+}
+
+// userKey maps a raw key back through the reverse gateway, and keeps it only
+// if the key gateway maps it to the same raw key.
+func (ra *redisAdapter) userKey(pattern string, rawKey string) (string, bool) {
+	key, ok := ra.reverseGateway(rawKey)
+	if !ok {
+		return "", false
+	}
+
+	if gated, ok := ra.keyGateway(key); !ok || gated != rawKey {
+		return "", false
+	}
+	return key, turing.MatchPattern(pattern, key)
+}
+
+func (ra *redisAdapter) scanCluster(client *redis.ClusterClient, pattern string, count int) turing.Iterator {
+	var mutex sync.Mutex
+	var keys []string
+	err := client.ForEachMaster(func (master *redis.Client) error {
+		it := master.Scan(0, ra.rawPattern(pattern), int64(count)).Iterator()
+		for it.Next() {
+			if key, ok := ra.userKey(pattern, it.Val()); ok {
+				mutex.Lock()
+				keys = append(keys, key)
+				mutex.Unlock()
+			}
+		}
+		return it.Err()
+	})
+
+	if err != nil {
+		return turing.NewErrorIterator(ra.convertError(err))
+	}
+	return turing.NewSliceIterator(keys, nil)
+}
+
+func (ra *redisAdapter) Scan(pattern string, count int) turing.Iterator {
+	if err := ra.ctx.Err(); err != nil {
+		return turing.NewErrorIterator(err)
+	} else if ra.reverseGateway == nil {
+		return turing.NewErrorIterator(turing.IrreversibleGatewayError)
+	}
+
+	if cluster, ok := ra.client.(*redis.ClusterClient); ok {
+		return ra.scanCluster(cluster, pattern, count)
+	}
+
+	return &redisScanIterator{
+		adapter: ra,
+		pattern: pattern,
+		it: ra.client.Scan(0, ra.rawPattern(pattern), int64(count)).Iterator(),
+	}
+}
+
+func (ra *redisAdapter) HScan(key string, pattern string, count int) turing.Iterator {
+	if err := ra.ctx.Err(); err != nil {
+		return turing.NewErrorIterator(err)
+	}
+
+	key, ex := ra.keyGateway(key)
+	if !ex {
+		return turing.NewSliceIterator(nil, nil)
+	}
+
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	return &redisHashScanIterator{
+		adapter: ra,
+		it: ra.client.HScan(key, 0, pattern, int64(count)).Iterator(),
+	}
+}
\ No newline at end of file
@@ -0,0 +1,163 @@
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"github.com/go-redis/redis"
+	"github.com/areller/turing"
+)
+
+const watchBufferSize = 1024
+
+type redisSubscription struct {
+	adapter *redisAdapter
+	pattern string
+	pubsubs []*redis.PubSub
+	events chan turing.KeyEvent
+	closeChan chan struct{}
+	closeOnce sync.Once
+	wg sync.WaitGroup
+}
+
+func keyEventType(event string) (turing.KeyEventType, bool) {
+	switch event {
+	case "del", "evicted", "rename_from", "move_from":
+		return turing.KeyDeleteEvent, true
+	case "expired":
+		return turing.KeyExpireEvent, true
+	case "expire", "persist", "new":
+		return 0, false
+	default:
+		return turing.KeySetEvent, true
+	}
+}
+
+func (rs *redisSubscription) forward(pubsub *redis.PubSub) {
+	defer rs.wg.Done()
+	for msg := range pubsub.Channel() {
+		idx := strings.Index(msg.Channel, "__:")
+		if idx < 0 {
+			continue
+		}
+
+		eventType, ok := keyEventType(msg.Payload)
+		if !ok {
+			continue
+		}
+
+		key, ok := rs.adapter.userKey(rs.pattern, msg.Channel[idx + 3:])
+		if !ok {
+			continue
+		}
+
+		select {
+		case rs.events <- turing.KeyEvent{ Type: eventType, Key: key }:
+		case <- rs.closeChan:
+			return
+		default:
+			turing.Log.WithFields(turing.LogFields{
+				"key": key,
+				"pattern": rs.pattern,
+			}).Warn("Watcher is too slow, closing it")
+			// Close waits for the forwarders, this one included.
+			go rs.Close()
+			return
+		}
+	}
+}
+
+func (rs *redisSubscription) Events() <-chan turing.KeyEvent {
+	return rs.events
+}
+
+func (rs *redisSubscription) Close() {
+	rs.closeOnce.Do(func () {
+		close(rs.closeChan)
+		for _, pubsub := range rs.pubsubs {
+			pubsub.Close()
+		}
+		rs.wg.Wait()
+		close(rs.events)
+	})
+}
+
+func (ra *redisAdapter) keyspaceChannel(db int, pattern string) string {
+	return fmt.Sprintf("__keyspace@%d__:%s", db, ra.rawPattern(pattern))
+}
+
+func (ra *redisAdapter) psubscribe(client *redis.Client, pattern string) (*redis.PubSub, error) {
+	pubsub := client.PSubscribe(ra.keyspaceChannel(client.Options().DB, pattern))
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	return pubsub, nil
+}
+
+// WatchPattern subscribes to keyspace notifications for keys matching
+// pattern. Notifications must be enabled on the server, see
+// EnableKeyspaceNotifications. In cluster mode every master is subscribed,
+// since notifications are not propagated between nodes.
+func (ra *redisAdapter) WatchPattern(pattern string) (turing.Subscription, error) {
+	if err := ra.ctx.Err(); err != nil {
+		return nil, err
+	} else if ra.reverseGateway == nil {
+		return nil, turing.IrreversibleGatewayError
+	}
+
+	var pubsubs []*redis.PubSub
+	var err error
+	switch client := ra.client.(type) {
+	case *redis.Client:
+		var pubsub *redis.PubSub
+		pubsub, err = ra.psubscribe(client, pattern)
+		if err == nil {
+			pubsubs = append(pubsubs, pubsub)
+		}
+	case *redis.ClusterClient:
+		var mutex sync.Mutex
+		err = client.ForEachMaster(func (master *redis.Client) error {
+			pubsub, err := ra.psubscribe(master, pattern)
+			if err != nil {
+				return err
+			}
+			mutex.Lock()
+			pubsubs = append(pubsubs, pubsub)
+			mutex.Unlock()
+			return nil
+		})
+	default:
+		pubsubs = append(pubsubs, ra.client.PSubscribe(ra.keyspaceChannel(0, pattern)))
+	}
+
+	if err != nil {
+		for _, pubsub := range pubsubs {
+			pubsub.Close()
+		}
+		return nil, ra.convertError(err)
+	}
+
+	sub := &redisSubscription{
+		adapter: ra,
+		pattern: pattern,
+		pubsubs: pubsubs,
+		events: make(chan turing.KeyEvent, watchBufferSize),
+		closeChan: make(chan struct{}),
+	}
+	for _, pubsub := range pubsubs {
+		sub.wg.Add(1)
+		go sub.forward(pubsub)
+	}
+	return sub, nil
+}
+
+func (ra *redisAdapter) EnableKeyspaceNotifications() error {
+	if cluster, ok := ra.client.(*redis.ClusterClient); ok {
+		return ra.convertError(cluster.ForEachMaster(func (master *redis.Client) error {
+			return master.ConfigSet("notify-keyspace-events", "KEA").Err()
+		}))
+	}
+
+	return ra.convertError(ra.client.ConfigSet("notify-keyspace-events", "KEA").Err())
+}
\ No newline at end of file
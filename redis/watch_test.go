@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"testing"
+	"time"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/areller/turing"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchPattern(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+	defer client.Close()
+
+	store := AdaptToKVStore(client, PrefixGateway("app:"), WithReverseGateway(PrefixReverseGateway("app:")))
+
+	sub, err := store.WatchPattern("user:*")
+	assert.Nil(t, err)
+	defer sub.Close()
+
+	server.Publish("__keyspace@0__:other:1", "set")
+	server.Publish("__keyspace@0__:app:user:1", "set")
+	server.Publish("__keyspace@0__:app:user:1", "expire")
+	server.Publish("__keyspace@0__:app:user:2", "hset")
+	server.Publish("__keyspace@0__:app:user:1", "expired")
+	server.Publish("__keyspace@0__:app:user:2", "del")
+
+	expected := []turing.KeyEvent{
+		{ Type: turing.KeySetEvent, Key: "user:1" },
+		{ Type: turing.KeySetEvent, Key: "user:2" },
+		{ Type: turing.KeyExpireEvent, Key: "user:1" },
+		{ Type: turing.KeyDeleteEvent, Key: "user:2" },
+	}
+	for _, e := range expected {
+		select {
+		case event := <- sub.Events():
+			assert.Equal(t, e, event)
+		case <- time.After(time.Second):
+			t.Fatal("Timed out waiting for key event")
+		}
+	}
+
+	sub.Close()
+	_, ok := <- sub.Events()
+	assert.False(t, ok)
+}
+
+func TestWatchPatternSlowSubscriber(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	client := redis.NewClient(&redis.Options{
+		Addr: server.Addr(),
+	})
+	defer client.Close()
+
+	store := AdaptToKVStore(client, PrefixGateway("app:"), WithReverseGateway(PrefixReverseGateway("app:")))
+
+	sub, err := store.WatchPattern("*")
+	assert.Nil(t, err)
+	defer sub.Close()
+
+	for i := 0; i < watchBufferSize; i++ {
+		server.Publish("__keyspace@0__:app:a", "set")
+	}
+	assert.Eventually(t, func () bool {
+		return len(sub.Events()) == watchBufferSize
+	}, 5 * time.Second, 5 * time.Millisecond)
+	server.Publish("__keyspace@0__:app:a", "set")
+
+	select {
+	case <- sub.(*redisSubscription).closeChan:
+	case <- time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the subscription to close")
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	assert.Equal(t, watchBufferSize, received)
+}
\ No newline at end of file
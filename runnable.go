@@ -1,8 +1,6 @@
 package turing
 
 import (
-	"os"
-	"os/signal"
 	"sync"
 	"errors"
 	"github.com/tevino/abool"
@@ -63,20 +61,15 @@ func NewCompositeRunnable(runnables []Runnable) *CompositeRunnable {
 	}
 }
 
-func RunInProcess(runnable Runnable) {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt)
+func RunInProcess(runnable Runnable) error {
+	return RunCompositeInProcess(runnable)
+}
 
-	go runnable.Run()
-	for {
-		select {
-		case <- sigChan:
-			runnable.Close()
-			return
-		}
+func RunCompositeInProcess(runnables ...Runnable) error {
+	lc := NewLifecycle(DefaultShutdownTimeout)
+	for _, r := range runnables {
+		lc.AddStage(r)
 	}
-}
 
-func RunCompositeInProcess(runnables ...Runnable) {
-	RunInProcess(NewCompositeRunnable(runnables))
+	return lc.Run()
 }
\ No newline at end of file
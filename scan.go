@@ -0,0 +1,160 @@
+package turing
+
+type Iterator interface {
+	Next() bool
+	Key() string
+	Value() string
+	Err() error
+}
+
+type ScanKVStore interface {
+	KVStore
+	Scan(pattern string, count int) Iterator
+	HScan(key string, pattern string, count int) Iterator
+}
+
+type sliceIterator struct {
+	keys []string
+	values []string
+	pos int
+	err error
+}
+
+func (si *sliceIterator) Next() bool {
+	if si.err != nil || si.pos >= len(si.keys) {
+		return false
+	}
+
+	si.pos++
+	return true
+}
+
+func (si *sliceIterator) Key() string {
+	if si.pos == 0 || si.pos > len(si.keys) {
+		return ""
+	}
+
+	return si.keys[si.pos - 1]
+}
+
+func (si *sliceIterator) Value() string {
+	if si.pos == 0 || si.pos > len(si.values) {
+		return ""
+	}
+
+	return si.values[si.pos - 1]
+}
+
+func (si *sliceIterator) Err() error {
+	return si.err
+}
+
+func NewSliceIterator(keys []string, values []string) Iterator {
+	return &sliceIterator{
+		keys: keys,
+		values: values,
+	}
+}
+
+func NewErrorIterator(err error) Iterator {
+	return &sliceIterator{
+		err: err,
+	}
+}
+
+// MatchPattern reports whether key matches a redis style glob pattern
+// (*, ?, [abc], [^a-z] and \ escapes). An empty pattern matches everything.
+func MatchPattern(pattern string, key string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	return matchPattern(pattern, key)
+}
+
+func matchPattern(pattern string, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			rest, ok := matchClass(pattern[1:], key[0])
+			if !ok {
+				return false
+			}
+			key = key[1:]
+			pattern = rest
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+
+	return len(key) == 0
+}
+
+func matchClass(pattern string, c byte) (string, bool) {
+	negate := false
+	if len(pattern) > 0 && pattern[0] == '^' {
+		negate = true
+		pattern = pattern[1:]
+	}
+
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		if pattern[0] == '\\' && len(pattern) > 1 {
+			pattern = pattern[1:]
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		} else if len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']' {
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			pattern = pattern[3:]
+		} else {
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+	return pattern, matched != negate
+}
\ No newline at end of file
@@ -0,0 +1,51 @@
+package turing
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		key string
+		match bool
+	}{
+		{ "", "anything", true },
+		{ "*", "", true },
+		{ "user:*", "user:1", true },
+		{ "user:*", "order:1", false },
+		{ "*:1", "user:1", true },
+		{ "u*r:*1", "user:21", true },
+		{ "user:?", "user:10", false },
+		{ "user:??", "user:10", true },
+		{ "h[ae]llo", "hallo", true },
+		{ "h[ae]llo", "hillo", false },
+		{ "h[^e]llo", "hallo", true },
+		{ "h[^e]llo", "hello", false },
+		{ "h[a-c]llo", "hbllo", true },
+		{ "h[a-c]llo", "hdllo", false },
+		{ "h\\*llo", "h*llo", true },
+		{ "h\\*llo", "hello", false },
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.match, MatchPattern(c.pattern, c.key), "%s ~ %s", c.pattern, c.key)
+	}
+}
+
+func TestSliceIterator(t *testing.T) {
+	it := NewSliceIterator([]string{ "a", "b" }, []string{ "1", "2" })
+	assert.Equal(t, "", it.Key())
+	assert.True(t, it.Next())
+	assert.Equal(t, "a", it.Key())
+	assert.Equal(t, "1", it.Value())
+	assert.True(t, it.Next())
+	assert.Equal(t, "b", it.Key())
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Err())
+
+	it = NewErrorIterator(GeneralError)
+	assert.False(t, it.Next())
+	assert.Equal(t, GeneralError, it.Err())
+}
\ No newline at end of file
@@ -1,32 +1,179 @@
 package turing
 
 import (
+	"net"
+	"sync"
 	"github.com/areller/turing/proto"
 	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+const defaultScanCount = 100
+
 type protoServerImpl struct {
+	store KVStore
+}
+
+func (psi *protoServerImpl) convertError(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case KeyNotExistsError:
+		return status.Error(codes.NotFound, err.Error())
+	case WrongTypeError:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case ConnectionDroppedError, NotConnectedError:
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (psi *protoServerImpl) scanStore() (ScanKVStore, error) {
+	scanStore, ok := psi.store.(ScanKVStore)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "store does not support scanning")
+	}
+
+	return scanStore, nil
+}
+
+func (psi *protoServerImpl) stream(ctx context.Context, it Iterator, send func (entry *proto.ScanEntry) error) error {
+	for it.Next() {
+		if err := ctx.Err(); err != nil {
+			return psi.convertError(err)
+		}
+
+		err := send(&proto.ScanEntry{
+			Key: it.Key(),
+			Value: it.Value(),
+		})
+		if err != nil {
+			return err
+		}
+	}
 
+	return psi.convertError(it.Err())
+}
+
+func scanCount(count int64) int {
+	if count <= 0 {
+		return defaultScanCount
+	}
+
+	return int(count)
 }
 
 func (psi *protoServerImpl) Get(ctx context.Context, sr *proto.StringRequest) (*proto.StringResponse, error) {
-	return nil, nil
+	value, err := KVStoreWithContext(ctx, psi.store).Get(sr.Key)
+	if err != nil {
+		return nil, psi.convertError(err)
+	}
+
+	return &proto.StringResponse{
+		Value: value,
+	}, nil
 }
 
-type Server struct {
+func (psi *protoServerImpl) HashGet(ctx context.Context, hfr *proto.HashFieldRequest) (*proto.StringResponse, error) {
+	value, err := KVStoreWithContext(ctx, psi.store).HGet(hfr.Key, hfr.Field)
+	if err != nil {
+		return nil, psi.convertError(err)
+	}
+
+	return &proto.StringResponse{
+		Value: value,
+	}, nil
+}
+
+func (psi *protoServerImpl) HashGetAll(ctx context.Context, sr *proto.StringRequest) (*proto.HashResponse, error) {
+	value, err := KVStoreWithContext(ctx, psi.store).HGetAll(sr.Key)
+	if err != nil {
+		return nil, psi.convertError(err)
+	}
+
+	return &proto.HashResponse{
+		Value: value,
+	}, nil
+}
+
+func (psi *protoServerImpl) Scan(sr *proto.ScanRequest, stream proto.KVStore_ScanServer) error {
+	scanStore, err := psi.scanStore()
+	if err != nil {
+		return err
+	}
+
+	it := scanStore.Scan(sr.Pattern, scanCount(sr.Count))
+	return psi.stream(stream.Context(), it, stream.Send)
+}
+
+func (psi *protoServerImpl) HashScan(hsr *proto.HashScanRequest, stream proto.KVStore_HashScanServer) error {
+	scanStore, err := psi.scanStore()
+	if err != nil {
+		return err
+	}
 
+	it := scanStore.HScan(hsr.Key, hsr.Pattern, scanCount(hsr.Count))
+	return psi.stream(stream.Context(), it, stream.Send)
+}
+
+type Server struct {
+	address string
+	server *grpc.Server
+	closeOnce sync.Once
 }
 
 func (s *Server) Close() {
+	s.closeOnce.Do(func () {
+		s.server.GracefulStop()
+	})
+}
+
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.server.Serve(listener)
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
 
+	return err
 }
 
 func (s *Server) Run() error {
-	return nil
+	if s.address == "" {
+		return NoAddressError
+	}
+
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
 }
 
+// NewServer serves store without an address, it can only be started with
+// Serve.
+//
+// Deprecated: use NewKVStoreServer, which also sets the address that Run
+// listens on.
 func NewServer(store KVStore) *Server {
-	return &Server{
+	return NewKVStoreServer("", store)
+}
 
+func NewKVStoreServer(address string, store KVStore, opts ...grpc.ServerOption) *Server {
+	server := grpc.NewServer(opts...)
+	proto.RegisterKVStoreServer(server, &protoServerImpl{
+		store: store,
+	})
+
+	return &Server{
+		address: address,
+		server: server,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,119 @@
+package turing
+
+import (
+	"io"
+	"net"
+	"testing"
+	"github.com/areller/turing/proto"
+	context "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"github.com/stretchr/testify/assert"
+)
+
+func startTestServer(t *testing.T, store KVStore) (proto.KVStoreClient, func ()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	server := NewKVStoreServer("", store)
+	done := make(chan error, 1)
+	go func () {
+		done <- server.Serve(listener)
+	}()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+
+	return proto.NewKVStoreClient(conn), func () {
+		conn.Close()
+		server.Close()
+		assert.Nil(t, <- done)
+	}
+}
+
+func TestServerGet(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+	client, stop := startTestServer(t, store)
+	defer stop()
+
+	store.Set("a", "value")
+	store.HSetMany("h", map[string]interface{}{ "x": "1", "y": "2" })
+
+	res, err := client.Get(context.Background(), &proto.StringRequest{ Key: "a" })
+	assert.Nil(t, err)
+	assert.Equal(t, "value", res.Value)
+
+	_, err = client.Get(context.Background(), &proto.StringRequest{ Key: "b" })
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err = client.HashGet(context.Background(), &proto.HashFieldRequest{ Key: "h", Field: "y" })
+	assert.Nil(t, err)
+	assert.Equal(t, "2", res.Value)
+
+	all, err := client.HashGetAll(context.Background(), &proto.StringRequest{ Key: "h" })
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{ "x": "1", "y": "2" }, all.Value)
+}
+
+func TestServerScan(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+	client, stop := startTestServer(t, store)
+	defer stop()
+
+	store.Set("user:1", "a")
+	store.Set("user:2", "b")
+	store.Set("order:1", "c")
+	store.HSetMany("h", map[string]interface{}{ "x": "1", "y": "2" })
+
+	stream, err := client.Scan(context.Background(), &proto.ScanRequest{ Pattern: "user:*" })
+	assert.Nil(t, err)
+	var keys []string
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		keys = append(keys, entry.Key)
+	}
+	assert.Equal(t, []string{ "user:1", "user:2" }, keys)
+
+	hstream, err := client.HashScan(context.Background(), &proto.HashScanRequest{ Key: "h" })
+	assert.Nil(t, err)
+	fields := make(map[string]string)
+	for {
+		entry, err := hstream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		fields[entry.Key] = entry.Value
+	}
+	assert.Equal(t, map[string]string{ "x": "1", "y": "2" }, fields)
+
+	hstream, err = client.HashScan(context.Background(), &proto.HashScanRequest{ Key: "user:1" })
+	assert.Nil(t, err)
+	_, err = hstream.Recv()
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestServerScanUnsupported(t *testing.T) {
+	client, stop := startTestServer(t, stubKVStore{})
+	defer stop()
+
+	stream, err := client.Scan(context.Background(), &proto.ScanRequest{})
+	assert.Nil(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestServerWithoutAddress(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	server := NewServer(store)
+	assert.Equal(t, NoAddressError, server.Run())
+}
\ No newline at end of file
@@ -1,8 +1,14 @@
 package turing
 
 import (
+	"context"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type partitionMessageTuple struct {
@@ -16,6 +22,15 @@ type SimpleProcessorContext struct {
 	TopicObject interface{}
 	ProcessorObject interface{}
 	Encoded EncodedKV
+	Context context.Context
+}
+
+func (spc SimpleProcessorContext) SpanContext() trace.SpanContext {
+	return trace.SpanContextFromContext(spc.Context)
+}
+
+func (spc SimpleProcessorContext) KVStore(store KVStore) KVStore {
+	return KVStoreWithContext(spc.Context, store)
 }
 
 type SimpleProcessorHandler func (context SimpleProcessorContext, msg DecodedKV) (err error, moveOn bool)
@@ -29,14 +44,38 @@ type SimpleProcessorTopicDefinition struct {
 
 func (sptd SimpleProcessorTopicDefinition) transformHandler(sp *SimpleProcessor) PartitionHandler {
 	return func (p *Partition, original EncodedKV, msg DecodedKV) {
-		for {
+		parent := p.Context()
+		if GetTracer() != nil {
+			parent = ExtractTraceHeaders(parent, original.Headers)
+		}
+
+		for attempt := 0; ; attempt++ {
+			if attempt > 0 {
+				GetMetrics().HandlerRetried(p.Topic, p.Id)
+			}
+
+			ctx, span := startSpan(parent, p.Topic + " process", trace.SpanKindConsumer,
+				attribute.String("messaging.system", "kafka"),
+				attribute.String("messaging.source.name", p.Topic),
+				attribute.Int64("messaging.kafka.partition", p.Id),
+				attribute.Int64("messaging.kafka.message.offset", p.offset),
+				attribute.Int("messaging.kafka.attempt", attempt))
+
+			start := time.Now()
 			err, moveOn := sptd.Handler(SimpleProcessorContext{
 				Partition: p,
 				TopicObject: sptd.Object,
 				Processor: sp,
 				ProcessorObject: sp.obj,
 				Encoded: original,
+				Context: ctx,
 			}, msg)
+			GetMetrics().MessageHandled(p.Topic, p.Id, time.Since(start))
+			endSpan(span, err)
+
+			if err != nil {
+				GetMetrics().MessageFailed(p.Topic, p.Id)
+			}
 
 			if err == FatalError {
 				Log.WithError(err).WithFields(logrus.Fields{
@@ -58,20 +97,37 @@ func (sptd SimpleProcessorTopicDefinition) transformHandler(sp *SimpleProcessor)
 				if moveOn {
 					break
 				}
+
+				if parent.Err() != nil {
+					p.abort()
+					break
+				}
 			}
 		}
 	}
 }
 
 type SimpleProcessor struct {
+	ctx context.Context
+	cancel context.CancelFunc
 	closeChan chan struct{}
 	pm *PartitionManager
 	runnable Runnable
 	obj interface{}
 	topics map[string]SimpleProcessorTopicDefinition
-	commitBehavior func (p *Partition, msg MessageEvent)
+	commitBehavior func (p *Partition, msg MessageEvent) error
 	offsetPickBehavior func (p *Partition) int64
 	commitChan chan partitionMessageTuple
+	commitCloseChan chan struct{}
+	commitDone chan struct{}
+	running int32
+	runWg sync.WaitGroup
+	closeOnce sync.Once
+	drainTimeout time.Duration
+	clock Clock
+	partitionsMutex sync.Mutex
+	partitionsWg sync.WaitGroup
+	partitions map[string]*Partition
 }
 
 func (sp *SimpleProcessor) handlePartitionCreation(p *Partition) {
@@ -80,6 +136,8 @@ func (sp *SimpleProcessor) handlePartitionCreation(p *Partition) {
 		return
 	}
 
+	p.SetContext(sp.ctx)
+	p.SetClock(sp.clock)
 	p.SetCodec(topicDef.Codec)
 	p.SetHandler(topicDef.transformHandler(sp))
 	p.SetCommitBehavior(func (p *Partition, msg MessageEvent) {
@@ -95,17 +153,33 @@ func (sp *SimpleProcessor) handlePartitionCreation(p *Partition) {
 				msg: msg,
 			}
 		} else {
-			sp.commitBehavior(p, msg)
+			sp.commit(p, msg)
 		}
 	})
 	p.SetOffset(sp.offsetPickBehavior(p))
 
+	// The pick may have been interrupted by Close, the partition must not be
+	// assigned with an offset that was not picked.
+	select {
+	case <- sp.closeChan:
+		return
+	default:
+	}
+
 	Log.WithFields(LogFields{
 		"topic": p.Topic,
 		"partition": p.Id,
 	}).Info("simple processor: assigned new partition")
 
-	go p.Run()
+	sp.partitionsMutex.Lock()
+	sp.partitions[p.String()] = p
+	sp.partitionsMutex.Unlock()
+
+	sp.partitionsWg.Add(1)
+	go func () {
+		p.Run()
+		sp.partitionsWg.Done()
+	}()
 }
 
 func (sp *SimpleProcessor) handlePartitionRemoval(p *Partition) {
@@ -114,26 +188,93 @@ func (sp *SimpleProcessor) handlePartitionRemoval(p *Partition) {
 		"partition": p.Id,
 	}).Info("simple processor: partition unassigned")
 
+	sp.partitionsMutex.Lock()
+	delete(sp.partitions, p.String())
+	sp.partitionsMutex.Unlock()
+
 	p.Close()
 }
 
-func (sp *SimpleProcessor) runCommitChan(closeChan chan struct{}) {
+func (sp *SimpleProcessor) HealthCheck(stuckThreshold time.Duration) HealthCheck {
+	return func () error {
+		if atomic.LoadInt32(&sp.running) == 0 {
+			return NotRunningError
+		}
+
+		sp.partitionsMutex.Lock()
+		defer sp.partitionsMutex.Unlock()
+		for _, p := range sp.partitions {
+			if !p.IsRunning() {
+				return NotRunningError
+			} else if p.Stuck(stuckThreshold) {
+				return PartitionStuckError
+			}
+		}
+
+		return nil
+	}
+}
+
+func (sp *SimpleProcessor) commit(p *Partition, msg MessageEvent) {
+	start := time.Now()
+	if err := sp.commitBehavior(p, msg); err != nil {
+		GetMetrics().CommitFailed(p.Topic, p.Id)
+		return
+	}
+
+	GetMetrics().Committed(p.Topic, p.Id, time.Since(start))
+}
+
+func (sp *SimpleProcessor) runCommitChan() {
+	defer close(sp.commitDone)
 	for {
 		select {
-		case <- closeChan:
-			return
+		case <- sp.commitCloseChan:
+			for {
+				select {
+				case c := <- sp.commitChan:
+					sp.commit(c.p, c.msg)
+				default:
+					return
+				}
+			}
 		case c := <- sp.commitChan:
-			sp.commitBehavior(c.p, c.msg)
+			sp.commit(c.p, c.msg)
 		}
 	}
 }
 
+func (sp *SimpleProcessor) drainPartitions() {
+	sp.partitionsMutex.Lock()
+	for _, p := range sp.partitions {
+		p.stop()
+	}
+	sp.partitionsMutex.Unlock()
+
+	if !tryWithTimeout(sp.clock, sp.drainTimeout, sp.partitionsWg.Wait) {
+		Log.WithFields(LogFields{
+			"timeout": sp.drainTimeout,
+		}).Warn("simple processor: handlers did not drain in time, cancelling their context")
+		sp.cancel()
+		sp.partitionsWg.Wait()
+	}
+}
+
+func (sp *SimpleProcessor) flushCommits() {
+	if sp.commitCloseChan == nil {
+		return
+	}
+
+	close(sp.commitCloseChan)
+	<- sp.commitDone
+}
+
 func (sp *SimpleProcessor) SetObject(obj interface{}) {
 	sp.obj = obj
 }
 
 func (sp *SimpleProcessor) SetCommitBehavior(behavior func (p *Partition, msg MessageEvent)) {
-	sp.commitBehavior = behavior
+	sp.commitBehavior = infallibleCommitBehavior(behavior)
 }
 
 func (sp *SimpleProcessor) SetDefaultCommitBehavior(consumer Consumer) {
@@ -142,7 +283,7 @@ func (sp *SimpleProcessor) SetDefaultCommitBehavior(consumer Consumer) {
 
 func (sp *SimpleProcessor) SetAsyncCommitBehavior(behavior func (p *Partition, msg MessageEvent), buffer int) {
 	sp.commitChan = make(chan partitionMessageTuple, buffer)
-	sp.commitBehavior = behavior
+	sp.commitBehavior = infallibleCommitBehavior(behavior)
 }
 
 func (sp *SimpleProcessor) SetAsyncDefaultCommitBehavior(consumer Consumer, buffer int) {
@@ -151,12 +292,18 @@ func (sp *SimpleProcessor) SetAsyncDefaultCommitBehavior(consumer Consumer, buff
 }
 
 func (sp *SimpleProcessor) SetKVStoreCommit(groupName string, store KVStore) {
-	sp.commitBehavior = func (p *Partition, msg MessageEvent) {
-		err := store.HSet("turing_" + p.Topic + "_" + groupName, strconv.FormatInt(p.Id, 10), msg.Offset)
-		if err == ConnectionDroppedError || UnrecongnizableError(err) {
-			Log.WithError(err).Panic("Could not commit offset to key-value store")
-			return
+	resilient := asResilientKVStore(store)
+	sp.commitBehavior = func (p *Partition, msg MessageEvent) error {
+		err := resilient.HSet("turing_" + p.Topic + "_" + groupName, strconv.FormatInt(p.Id, 10), msg.Offset)
+		if err != nil {
+			Log.WithError(err).WithFields(LogFields{
+				"topic": p.Topic,
+				"partition": p.Id,
+				"offset": msg.Offset,
+			}).Error("Could not commit offset to key-value store")
 		}
+
+		return err
 	}
 }
 
@@ -165,66 +312,107 @@ func (sp *SimpleProcessor) SetAsyncKVStoreCommit(groupName string, store KVStore
 	sp.commitChan = make(chan partitionMessageTuple, buffer)
 }
 
+func (sp *SimpleProcessor) SetDrainTimeout(timeout time.Duration) {
+	sp.drainTimeout = timeout
+}
+
+func (sp *SimpleProcessor) SetClock(clock Clock) {
+	sp.clock = clock
+}
+
 func (sp *SimpleProcessor) SetOffsetPickBehavior(behavior func (p *Partition) int64) {
 	sp.offsetPickBehavior = behavior
 }
 
+// SetKVStoreOffsetPick picks the offset of a partition from the key-value
+// store. If the store can't be reached the pick is retried until it succeeds
+// or the processor is closed, falling back to another source of offsets
+// would replay or skip messages.
 func (sp *SimpleProcessor) SetKVStoreOffsetPick(groupName string, store KVStore) {
+	resilient := asResilientKVStore(store)
 	sp.offsetPickBehavior = func (p *Partition) int64 {
-		off, err := store.HGet("turing_" + p.Topic + "_" + groupName, strconv.FormatInt(p.Id, 10))
-		if err == KeyNotExistsError {
-			return OffsetStored
-		} else if err == ConnectionDroppedError || UnrecongnizableError(err) {
-			Log.WithError(err).Panic("Could not fetch offset from key-value store")
-			return OffsetNone
-		} else {
-			offNum, err := strconv.ParseInt(off, 10, 64)
-			if err != nil {
+		for attempt := 0; ; attempt++ {
+			var off int64
+			err := HGetInto(resilient, new(PlainValueCodec), "turing_" + p.Topic + "_" + groupName, strconv.FormatInt(p.Id, 10), &off)
+			if err == KeyNotExistsError || err == WrongTypeError || err == InvalidValueError {
 				return OffsetStored
+			} else if err == nil {
+				return off + 1
+			}
+
+			Log.WithError(err).WithFields(LogFields{
+				"topic": p.Topic,
+				"partition": p.Id,
+			}).Error("Could not fetch offset from key-value store, retrying")
+
+			timer := sp.clock.NewTimer(resilient.backoff(attempt))
+			select {
+			case <- sp.closeChan:
+				timer.Stop()
+				return OffsetNone
+			case <- timer.C():
 			}
-			return offNum + 1
 		}
 	}
 }
 
 func (sp *SimpleProcessor) SetKVStoreBehavior(groupName string, store KVStore) {
+	store = asResilientKVStore(store)
 	sp.SetKVStoreCommit(groupName, store)
 	sp.SetKVStoreOffsetPick(groupName, store)
 }
 
 func (sp *SimpleProcessor) SetAsyncKVStoreBehavior(groupName string, store KVStore, buffer int) {
+	store = asResilientKVStore(store)
 	sp.SetAsyncKVStoreCommit(groupName, store, buffer)
 	sp.SetKVStoreOffsetPick(groupName, store)
 }
 
 func (sp *SimpleProcessor) Close() {
-	sp.pm.Close()
-	if sp.runnable != nil {
-		sp.runnable.Close()
-	}
-	
-	close(sp.closeChan)
+	sp.closeOnce.Do(func () {
+		sp.pm.Close()
+		close(sp.closeChan)
+		sp.runWg.Wait()
+
+		sp.drainPartitions()
+		sp.cancel()
+		sp.flushCommits()
+
+		if sp.runnable != nil {
+			sp.runnable.Close()
+		}
+	})
 }
 
 func (sp *SimpleProcessor) Run() error {
+	sp.runWg.Add(1)
+	defer sp.runWg.Done()
+	atomic.StoreInt32(&sp.running, 1)
+	defer atomic.StoreInt32(&sp.running, 0)
+
 	go sp.pm.Run()
+
+	runnableErr := make(chan error, 1)
 	if sp.runnable != nil {
-		go sp.runnable.Run()
+		go func () {
+			runnableErr <- sp.runnable.Run()
+		}()
 	}
 
-	var commitCloseChan chan struct{} = nil
-	if sp.commitChan != nil {
-		commitCloseChan = make(chan struct{})
-		go sp.runCommitChan(commitCloseChan)
+	if sp.commitChan != nil && sp.commitCloseChan == nil {
+		sp.commitCloseChan = make(chan struct{})
+		sp.commitDone = make(chan struct{})
+		go sp.runCommitChan()
 	}
 
 	for {
 		select {
 		case <- sp.closeChan:
-			if commitCloseChan != nil {
-				close(commitCloseChan)
-			}
 			return nil
+		case err := <- runnableErr:
+			if err != nil {
+				return err
+			}
 		case cp := <- sp.pm.CreatedPartition:
 			sp.handlePartitionCreation(cp)
 		case rp := <- sp.pm.RemovedPartition:
@@ -250,9 +438,19 @@ func covertToTopicsMap(topics []SimpleProcessorTopicDefinition) (map[string]Simp
 	return topicsMap, topicsNames, nil
 }
 
-func defaultCommitBehavior(consumer Consumer) func (p *Partition, msg MessageEvent) {
-	return func (p *Partition, msg MessageEvent) {
+func defaultCommitBehavior(consumer Consumer) func (p *Partition, msg MessageEvent) error {
+	return func (p *Partition, msg MessageEvent) error {
 		consumer.Commit(p.Topic, p.Id, msg.Offset)
+		return nil
+	}
+}
+
+// infallibleCommitBehavior adapts a user commit behavior, which reports its
+// own failures, to the processor's.
+func infallibleCommitBehavior(behavior func (p *Partition, msg MessageEvent)) func (p *Partition, msg MessageEvent) error {
+	return func (p *Partition, msg MessageEvent) error {
+		behavior(p, msg)
+		return nil
 	}
 }
 
@@ -270,13 +468,19 @@ func NewSimpleProcessor(consumer Consumer, runnable Runnable, topics []SimplePro
 
 	consumer.Subscribe(topicsNames)
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return &SimpleProcessor{
+		ctx: ctx,
+		cancel: cancel,
 		closeChan: make(chan struct{}),
 		pm: NewPartitionManager(consumer),
 		runnable: runnable,
 		topics: topicsMap,
 		commitChan: nil,
 		commitBehavior: defaultCommitBehavior(consumer),
+		drainTimeout: 10 * time.Second,
+		clock: SystemClock,
+		partitions: make(map[string]*Partition),
 		offsetPickBehavior: defaultOffsetPickBehavior(),
 	}, nil
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package turing
 import (
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestFailToDuplicateTopics(t *testing.T) {
@@ -99,6 +100,7 @@ func TestMessageProcessing(t *testing.T) {
 	sp.SetObject("processorobject")
 
 	go sp.Run()
+	defer sp.Close()
 	consumer.CreatePartitionEvent(PartitionEvent{
 		Type: PartitionCreated,
 		Topic: "topicA",
@@ -126,4 +128,118 @@ func TestMessageProcessing(t *testing.T) {
 	msg := <- chanA
 	assert.Equal(t, "key00", msg.Key)
 	assert.Equal(t, "value00", msg.Value.(string))
+}
+
+func TestContextCancelledOnRevoke(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	committed := make(chan struct{}, 1)
+
+	consumer := NewConsumerMock()
+	sp, _ := NewSimpleProcessor(consumer, nil, []SimpleProcessorTopicDefinition{
+		SimpleProcessorTopicDefinition{
+			Name: "myTopic",
+			Codec: new(StringCodec),
+			Handler: func (ctx SimpleProcessorContext, msg DecodedKV) (error, bool) {
+				close(started)
+				<- ctx.Context.Done()
+				close(cancelled)
+				return ctx.Context.Err(), false
+			},
+		},
+	})
+	sp.SetCommitBehavior(func (p *Partition, msg MessageEvent) {
+		committed <- struct{}{}
+	})
+
+	go sp.Run()
+	defer sp.Close()
+
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 0,
+	})
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 1,
+	})
+	consumer.CreateMessageEvent(MessageEvent{
+		Topic: "myTopic",
+		PartitionId: 0,
+		Offset: 0,
+		Key: []byte("myKey"),
+		Value: []byte("My Message"),
+	})
+
+	<- started
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionDestroyed,
+		Topic: "myTopic",
+		Id: 0,
+	})
+
+	assert.True(t, tryWithTimeout(SystemClock, time.Second, func () {
+		<- cancelled
+	}))
+	assert.False(t, tryWithTimeout(SystemClock, 100 * time.Millisecond, func () {
+		<- committed
+	}))
+}
+
+func TestCloseDrainsHandlersAndCommits(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var committed []int64
+
+	consumer := NewConsumerMock()
+	sp, _ := NewSimpleProcessor(consumer, nil, []SimpleProcessorTopicDefinition{
+		SimpleProcessorTopicDefinition{
+			Name: "myTopic",
+			Codec: new(StringCodec),
+			Handler: func (ctx SimpleProcessorContext, msg DecodedKV) (error, bool) {
+				close(started)
+				<- release
+				return nil, true
+			},
+		},
+	})
+	sp.SetAsyncCommitBehavior(func (p *Partition, msg MessageEvent) {
+		committed = append(committed, msg.Offset)
+	}, 10)
+
+	go sp.Run()
+
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 0,
+	})
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 1,
+	})
+	consumer.CreateMessageEvent(MessageEvent{
+		Topic: "myTopic",
+		PartitionId: 0,
+		Offset: 3,
+		Key: []byte("myKey"),
+		Value: []byte("My Message"),
+	})
+
+	<- started
+	closed := make(chan struct{})
+	go func () {
+		sp.Close()
+		close(closed)
+	}()
+
+	assert.False(t, tryWithTimeout(SystemClock, 50 * time.Millisecond, func () {
+		<- closed
+	}))
+	close(release)
+	<- closed
+	assert.Equal(t, []int64{ 3 }, committed)
 }
\ No newline at end of file
@@ -0,0 +1,315 @@
+package turing
+
+import (
+	"sync"
+	"time"
+)
+
+type RestartStrategy int
+
+const (
+	OneForOne RestartStrategy = iota
+	OneForAll
+	RestForOne
+)
+
+type RunnableFactory func () (Runnable, error)
+
+type SupervisorChild struct {
+	Name string
+	Factory RunnableFactory
+	Transient bool
+}
+
+type SupervisorHooks struct {
+	OnStart func (name string)
+	OnExit func (name string, err error)
+	OnRestart func (name string, restarts int)
+	OnGiveUp func (err error)
+}
+
+type supervisedChild struct {
+	spec SupervisorChild
+	runnable Runnable
+	generation int
+	done chan struct{}
+}
+
+type childExit struct {
+	index int
+	generation int
+	err error
+}
+
+type Supervisor struct {
+	name string
+	strategy RestartStrategy
+	children []*supervisedChild
+	maxRestarts int
+	period time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	hooks SupervisorHooks
+	restarts []time.Time
+	clock Clock
+	exits chan childExit
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *Supervisor) SetRestartIntensity(maxRestarts int, period time.Duration) {
+	s.maxRestarts = maxRestarts
+	s.period = period
+}
+
+func (s *Supervisor) SetBackoff(min time.Duration, max time.Duration) {
+	s.minBackoff = min
+	s.maxBackoff = max
+}
+
+func (s *Supervisor) SetHooks(hooks SupervisorHooks) {
+	s.hooks = hooks
+}
+
+func (s *Supervisor) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+func (s *Supervisor) start(index int) error {
+	child := s.children[index]
+	child.generation++
+	child.runnable = nil
+
+	runnable, err := child.spec.Factory()
+	if err != nil {
+		Log.WithError(err).WithFields(LogFields{
+			"supervisor": s.name,
+			"child": child.spec.Name,
+		}).Error("supervisor: could not create child")
+		return err
+	}
+
+	child.runnable = runnable
+	child.done = make(chan struct{})
+	if s.hooks.OnStart != nil {
+		s.hooks.OnStart(child.spec.Name)
+	}
+
+	go func (generation int, done chan struct{}) {
+		err := runnable.Run()
+		close(done)
+		select {
+		case s.exits <- childExit{
+			index: index,
+			generation: generation,
+			err: err,
+		}:
+		case <- s.closeChan:
+		}
+	}(child.generation, child.done)
+
+	return nil
+}
+
+func (s *Supervisor) stop(index int) {
+	child := s.children[index]
+	child.generation++
+	if child.runnable == nil {
+		return
+	}
+
+	child.runnable.Close()
+	<- child.done
+	child.runnable = nil
+}
+
+func (s *Supervisor) stopAll() {
+	for i := len(s.children) - 1; i >= 0; i-- {
+		s.stop(i)
+	}
+}
+
+func (s *Supervisor) affected(index int) []int {
+	var indices []int
+	for i := range s.children {
+		switch s.strategy {
+		case OneForOne:
+			if i == index {
+				indices = append(indices, i)
+			}
+		case OneForAll:
+			indices = append(indices, i)
+		case RestForOne:
+			if i >= index {
+				indices = append(indices, i)
+			}
+		}
+	}
+	return indices
+}
+
+func (s *Supervisor) exceeded(now time.Time) bool {
+	var recent []time.Time
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.period {
+			recent = append(recent, t)
+		}
+	}
+
+	s.restarts = append(recent, now)
+	return len(s.restarts) > s.maxRestarts
+}
+
+func (s *Supervisor) backoff() time.Duration {
+	delay := s.minBackoff
+	for i := 1; i < len(s.restarts) && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+	return delay
+}
+
+func (s *Supervisor) wait(delay time.Duration) bool {
+	timer := s.clock.NewTimer(delay)
+	select {
+	case <- s.closeChan:
+		timer.Stop()
+		return false
+	case <- timer.C():
+		return true
+	}
+}
+
+func (s *Supervisor) startWithRetries(index int) error {
+	for s.start(index) != nil {
+		if s.exceeded(s.clock.Now()) {
+			return RestartIntensityError
+		}
+
+		if !s.wait(s.backoff()) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *Supervisor) restart(index int) error {
+	if s.exceeded(s.clock.Now()) {
+		return RestartIntensityError
+	}
+
+	indices := s.affected(index)
+	for i := len(indices) - 1; i >= 0; i-- {
+		s.stop(indices[i])
+	}
+
+	if !s.wait(s.backoff()) {
+		return nil
+	}
+
+	for _, i := range indices {
+		GetMetrics().RunnableRestarted(s.name, s.children[i].spec.Name)
+		if s.hooks.OnRestart != nil {
+			s.hooks.OnRestart(s.children[i].spec.Name, len(s.restarts))
+		}
+
+		Log.WithFields(LogFields{
+			"supervisor": s.name,
+			"child": s.children[i].spec.Name,
+		}).Warn("supervisor: restarting child")
+
+		if err := s.startWithRetries(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Supervisor) giveUp(err error) error {
+	Log.WithError(err).WithFields(LogFields{
+		"supervisor": s.name,
+	}).Error("supervisor: giving up")
+
+	s.stopAll()
+	s.Close()
+	if s.hooks.OnGiveUp != nil {
+		s.hooks.OnGiveUp(err)
+	}
+	return err
+}
+
+func (s *Supervisor) handleExit(exit childExit) error {
+	child := s.children[exit.index]
+	if exit.generation != child.generation {
+		return nil
+	}
+
+	child.runnable = nil
+	if s.hooks.OnExit != nil {
+		s.hooks.OnExit(child.spec.Name, exit.err)
+	}
+
+	if exit.err == nil && child.spec.Transient {
+		return nil
+	}
+
+	Log.WithError(exit.err).WithFields(LogFields{
+		"supervisor": s.name,
+		"child": child.spec.Name,
+	}).Error("supervisor: child exited")
+
+	return s.restart(exit.index)
+}
+
+func (s *Supervisor) Close() {
+	s.closeOnce.Do(func () {
+		close(s.closeChan)
+	})
+}
+
+func (s *Supervisor) Run() error {
+	for i := range s.children {
+		if err := s.startWithRetries(i); err != nil {
+			return s.giveUp(err)
+		}
+	}
+
+	for {
+		select {
+		case <- s.closeChan:
+			s.stopAll()
+			return nil
+		case exit := <- s.exits:
+			if err := s.handleExit(exit); err != nil {
+				return s.giveUp(err)
+			}
+		}
+	}
+}
+
+func NewSupervisor(name string, strategy RestartStrategy, children []SupervisorChild) *Supervisor {
+	supervised := make([]*supervisedChild, len(children))
+	for i, c := range children {
+		supervised[i] = &supervisedChild{
+			spec: c,
+		}
+	}
+
+	return &Supervisor{
+		name: name,
+		strategy: strategy,
+		children: supervised,
+		maxRestarts: 3,
+		period: 5 * time.Second,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 10 * time.Second,
+		clock: SystemClock,
+		exits: make(chan childExit, len(children)),
+		closeChan: make(chan struct{}),
+	}
+}
\ No newline at end of file
@@ -0,0 +1,159 @@
+package turing
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+type supervisorProbe struct {
+	mutex sync.Mutex
+	builds map[string]int
+	failures chan error
+}
+
+func (sp *supervisorProbe) get(name string) int {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+	return sp.builds[name]
+}
+
+func (sp *supervisorProbe) child(name string, failFirst bool) SupervisorChild {
+	return SupervisorChild{
+		Name: name,
+		Factory: func () (Runnable, error) {
+			sp.mutex.Lock()
+			sp.builds[name]++
+			build := sp.builds[name]
+			sp.mutex.Unlock()
+
+			closeChan := make(chan struct{})
+			return newAnnonymousRunner(func () error {
+				if failFirst && build == 1 {
+					return errors.New("child failed")
+				}
+				<- closeChan
+				return nil
+			}, func () {
+				close(closeChan)
+			}), nil
+		},
+	}
+}
+
+func newSupervisorProbe() *supervisorProbe {
+	return &supervisorProbe{
+		builds: make(map[string]int),
+	}
+}
+
+func runSupervisor(t *testing.T, strategy RestartStrategy, children func (probe *supervisorProbe) []SupervisorChild) *supervisorProbe {
+	probe := newSupervisorProbe()
+	restarted := make(chan struct{}, 10)
+
+	supervisor := NewSupervisor("test", strategy, children(probe))
+	supervisor.SetBackoff(time.Millisecond, 10 * time.Millisecond)
+	supervisor.SetHooks(SupervisorHooks{
+		OnRestart: func (name string, restarts int) {
+			restarted <- struct{}{}
+		},
+	})
+
+	done := make(chan error)
+	go func () {
+		done <- supervisor.Run()
+	}()
+
+	select {
+	case <- restarted:
+	case <- time.After(time.Second):
+		t.Fatal("child was not restarted")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	supervisor.Close()
+	assert.Nil(t, <- done)
+	return probe
+}
+
+func TestSupervisorOneForOne(t *testing.T) {
+	probe := runSupervisor(t, OneForOne, func (probe *supervisorProbe) []SupervisorChild {
+		return []SupervisorChild{ probe.child("a", false), probe.child("b", true), probe.child("c", false) }
+	})
+
+	assert.Equal(t, 1, probe.get("a"))
+	assert.Equal(t, 2, probe.get("b"))
+	assert.Equal(t, 1, probe.get("c"))
+}
+
+func TestSupervisorOneForAll(t *testing.T) {
+	probe := runSupervisor(t, OneForAll, func (probe *supervisorProbe) []SupervisorChild {
+		return []SupervisorChild{ probe.child("a", false), probe.child("b", true), probe.child("c", false) }
+	})
+
+	assert.Equal(t, 2, probe.get("a"))
+	assert.Equal(t, 2, probe.get("b"))
+	assert.Equal(t, 2, probe.get("c"))
+}
+
+func TestSupervisorRestForOne(t *testing.T) {
+	probe := runSupervisor(t, RestForOne, func (probe *supervisorProbe) []SupervisorChild {
+		return []SupervisorChild{ probe.child("a", false), probe.child("b", true), probe.child("c", false) }
+	})
+
+	assert.Equal(t, 1, probe.get("a"))
+	assert.Equal(t, 2, probe.get("b"))
+	assert.Equal(t, 2, probe.get("c"))
+}
+
+func TestSupervisorRestartIntensity(t *testing.T) {
+	var gaveUp error
+	supervisor := NewSupervisor("test", OneForOne, []SupervisorChild{
+		SupervisorChild{
+			Name: "failing",
+			Factory: func () (Runnable, error) {
+				return newAnnonymousRunner(func () error {
+					return errors.New("child failed")
+				}, func () { }), nil
+			},
+		},
+	})
+	supervisor.SetRestartIntensity(2, time.Second)
+	supervisor.SetBackoff(time.Millisecond, time.Millisecond)
+	supervisor.SetHooks(SupervisorHooks{
+		OnGiveUp: func (err error) {
+			gaveUp = err
+		},
+	})
+
+	assert.Equal(t, RestartIntensityError, supervisor.Run())
+	assert.Equal(t, RestartIntensityError, gaveUp)
+}
+
+func TestSupervisorTransientChild(t *testing.T) {
+	builds := 0
+	supervisor := NewSupervisor("test", OneForOne, []SupervisorChild{
+		SupervisorChild{
+			Name: "transient",
+			Transient: true,
+			Factory: func () (Runnable, error) {
+				builds++
+				return newAnnonymousRunner(func () error {
+					return nil
+				}, func () { }), nil
+			},
+		},
+	})
+
+	done := make(chan error)
+	go func () {
+		done <- supervisor.Run()
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	supervisor.Close()
+	assert.Nil(t, <- done)
+	assert.Equal(t, 1, builds)
+}
\ No newline at end of file
@@ -0,0 +1,311 @@
+package tester
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"github.com/areller/turing"
+)
+
+type TopicPartition struct {
+	Topic string
+	Partition int64
+}
+
+func (tp TopicPartition) String() string {
+	return tp.Topic + "_" + strconv.FormatInt(tp.Partition, 10)
+}
+
+func sortTopicPartitions(tps []TopicPartition) {
+	sort.Slice(tps, func (i, j int) bool {
+		if tps[i].Topic == tps[j].Topic {
+			return tps[i].Partition < tps[j].Partition
+		}
+		return tps[i].Topic < tps[j].Topic
+	})
+}
+
+type brokerTopic struct {
+	partitions [][]turing.MessageEvent
+	nextPartition int
+}
+
+// pinned holds partitions that are left out of automatic assignment until
+// the next Rebalance. The first Revoke or Reassign pins the current
+// assignment of the whole group so that only the targeted partition moves.
+type consumerGroup struct {
+	members []*Consumer
+	committed map[TopicPartition]int64
+	pinned map[TopicPartition]*Consumer
+}
+
+type Broker struct {
+	topics map[string]*brokerTopic
+	groups map[string]*consumerGroup
+	consumers map[*Consumer]struct{}
+	changed *sync.Cond
+	mutex sync.Mutex
+}
+
+func (b *Broker) CreateTopic(name string, partitions int) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.topics[name]; ok {
+		return turing.TopicExistsError
+	}
+
+	b.topics[name] = &brokerTopic{
+		partitions: make([][]turing.MessageEvent, partitions),
+	}
+	for name := range b.groups {
+		b.rebalanceLocked(name)
+	}
+	return nil
+}
+
+func (b *Broker) Partitions(topic string) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	t, ok := b.topics[topic]
+	if !ok {
+		return 0, turing.TopicNotExistsError
+	}
+	return len(t.partitions), nil
+}
+
+func (b *Broker) Produce(topic string, key []byte, value []byte, headers []turing.Header) (int64, int64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	t, ok := b.topics[topic]
+	if !ok {
+		return 0, 0, turing.TopicNotExistsError
+	}
+
+	var partition int64
+	if key == nil {
+		partition = int64(t.nextPartition % len(t.partitions))
+		t.nextPartition++
+	} else {
+		partition = PartitionForKey(key, len(t.partitions))
+	}
+
+	offset := int64(len(t.partitions[partition]))
+	t.partitions[partition] = append(t.partitions[partition], turing.MessageEvent{
+		Topic: topic,
+		PartitionId: partition,
+		Offset: offset,
+		Key: key,
+		Value: value,
+		Headers: headers,
+	})
+
+	for c := range b.consumers {
+		c.signal()
+	}
+	return partition, offset, nil
+}
+
+func (b *Broker) Messages(topic string, partition int64) []turing.MessageEvent {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	t, ok := b.topics[topic]
+	if !ok || partition < 0 || partition >= int64(len(t.partitions)) {
+		return nil
+	}
+
+	messages := make([]turing.MessageEvent, len(t.partitions[partition]))
+	copy(messages, t.partitions[partition])
+	return messages
+}
+
+func (b *Broker) highWatermarkLocked(tp TopicPartition) int64 {
+	t, ok := b.topics[tp.Topic]
+	if !ok || tp.Partition < 0 || tp.Partition >= int64(len(t.partitions)) {
+		return 0
+	}
+	return int64(len(t.partitions[tp.Partition]))
+}
+
+func (b *Broker) HighWatermark(topic string, partition int64) int64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.highWatermarkLocked(TopicPartition{ Topic: topic, Partition: partition })
+}
+
+func (b *Broker) Committed(group string, topic string, partition int64) (int64, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	g, ok := b.groups[group]
+	if !ok {
+		return 0, false
+	}
+	off, ok := g.committed[TopicPartition{ Topic: topic, Partition: partition }]
+	return off, ok
+}
+
+func (b *Broker) groupLocked(name string) *consumerGroup {
+	g, ok := b.groups[name]
+	if !ok {
+		g = &consumerGroup{
+			committed: make(map[TopicPartition]int64),
+			pinned: make(map[TopicPartition]*Consumer),
+		}
+		b.groups[name] = g
+	}
+	return g
+}
+
+func (b *Broker) joinLocked(c *Consumer) {
+	g := b.groupLocked(c.group)
+	for _, m := range g.members {
+		if m == c {
+			return
+		}
+	}
+	g.members = append(g.members, c)
+}
+
+func (b *Broker) leaveLocked(c *Consumer) {
+	g, ok := b.groups[c.group]
+	if !ok {
+		return
+	}
+	for i, m := range g.members {
+		if m == c {
+			g.members = append(g.members[:i], g.members[i + 1:]...)
+			break
+		}
+	}
+	for tp, owner := range g.pinned {
+		if owner == c {
+			delete(g.pinned, tp)
+		}
+	}
+	delete(b.consumers, c)
+	c.setAssignmentLocked(nil)
+	b.rebalanceLocked(c.group)
+}
+
+// rebalanceLocked spreads the partitions of every subscribed topic over the
+// group members subscribed to it, in join order, like Kafka's range assignor.
+func (b *Broker) rebalanceLocked(group string) {
+	g, ok := b.groups[group]
+	if !ok {
+		return
+	}
+
+	assignments := make(map[*Consumer][]TopicPartition)
+	topics := make(map[string][]*Consumer)
+	for _, m := range g.members {
+		assignments[m] = nil
+		for _, topic := range m.topics {
+			if _, ok := b.topics[topic]; ok {
+				topics[topic] = append(topics[topic], m)
+			}
+		}
+	}
+
+	for tp, owner := range g.pinned {
+		if _, ok := assignments[owner]; ok {
+			assignments[owner] = append(assignments[owner], tp)
+		}
+	}
+
+	for topic, members := range topics {
+		var free []TopicPartition
+		for p := range b.topics[topic].partitions {
+			tp := TopicPartition{ Topic: topic, Partition: int64(p) }
+			if _, ok := g.pinned[tp]; !ok {
+				free = append(free, tp)
+			}
+		}
+
+		per := len(free) / len(members)
+		extra := len(free) % len(members)
+		next := 0
+		for i, m := range members {
+			count := per
+			if i < extra {
+				count++
+			}
+			assignments[m] = append(assignments[m], free[next:next + count]...)
+			next += count
+		}
+	}
+
+	for m, tps := range assignments {
+		m.setAssignmentLocked(tps)
+	}
+}
+
+func (b *Broker) pinLocked(group string, tp TopicPartition, owner *Consumer) {
+	t, ok := b.topics[tp.Topic]
+	if !ok || tp.Partition < 0 || tp.Partition >= int64(len(t.partitions)) {
+		return
+	}
+
+	g := b.groupLocked(group)
+	for _, m := range g.members {
+		for mtp := range m.positions {
+			if _, ok := g.pinned[mtp]; !ok {
+				g.pinned[mtp] = m
+			}
+		}
+	}
+
+	g.pinned[tp] = owner
+	b.rebalanceLocked(group)
+}
+
+// Revoke takes a partition away from its owner in group, it stays
+// unassigned until Reassign or Rebalance is called.
+func (b *Broker) Revoke(group string, topic string, partition int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pinLocked(group, TopicPartition{ Topic: topic, Partition: partition }, nil)
+}
+
+// Reassign moves a partition to consumer, revoking it from its previous
+// owner in the consumer's group.
+func (b *Broker) Reassign(topic string, partition int64, consumer *Consumer) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pinLocked(consumer.group, TopicPartition{ Topic: topic, Partition: partition }, consumer)
+}
+
+// Rebalance revokes every partition from every member of group and assigns
+// them again from scratch, dropping any Revoke or Reassign overrides.
+func (b *Broker) Rebalance(group string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	g, ok := b.groups[group]
+	if !ok {
+		return
+	}
+
+	g.pinned = make(map[TopicPartition]*Consumer)
+	for _, m := range g.members {
+		m.setAssignmentLocked(nil)
+	}
+	b.rebalanceLocked(group)
+}
+
+func (b *Broker) NewProducer() *Producer {
+	return &Producer{
+		broker: b,
+	}
+}
+
+func (b *Broker) NewConsumer(group string) *Consumer {
+	return newConsumer(b, group)
+}
+
+func NewBroker() *Broker {
+	b := &Broker{
+		topics: make(map[string]*brokerTopic),
+		groups: make(map[string]*consumerGroup),
+		consumers: make(map[*Consumer]struct{}),
+	}
+	b.changed = sync.NewCond(&b.mutex)
+	return b
+}
\ No newline at end of file
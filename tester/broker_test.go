@@ -0,0 +1,346 @@
+package tester
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMurmur2(t *testing.T) {
+	cases := map[string]int32{
+		"21": -973932308,
+		"foobar": -790332482,
+		"a-little-bit-long-string": -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+
+	for in, out := range cases {
+		assert.Equal(t, out, Murmur2([]byte(in)), in)
+	}
+}
+
+func TestProducePartitioning(t *testing.T) {
+	broker := NewBroker()
+	assert.Nil(t, broker.CreateTopic("topic", 4))
+	assert.Equal(t, turing.TopicExistsError, broker.CreateTopic("topic", 4))
+
+	producer := broker.NewProducer()
+	assert.Equal(t, turing.TopicNotExistsError, producer.Send("missing", nil, nil))
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, producer.Send("topic", []byte("key"), []byte("value")))
+	}
+	partition := PartitionForKey([]byte("key"), 4)
+	assert.Len(t, broker.Messages("topic", partition), 3)
+	assert.Equal(t, int64(2), broker.Messages("topic", partition)[2].Offset)
+
+	for i := 0; i < 4; i++ {
+		producer.Send("topic", nil, []byte("value"))
+	}
+	total := int64(0)
+	for p := int64(0); p < 4; p++ {
+		total += broker.HighWatermark("topic", p)
+	}
+	assert.Equal(t, int64(7), total)
+}
+
+type recordingProcessor struct {
+	sp *turing.SimpleProcessor
+	mutex sync.Mutex
+	values []string
+}
+
+func (rp *recordingProcessor) received() []string {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	values := make([]string, len(rp.values))
+	copy(values, rp.values)
+	return values
+}
+
+func startProcessor(t *testing.T, consumer *Consumer, topic string) *recordingProcessor {
+	return runProcessor(t, consumer, consumer, topic)
+}
+
+func runProcessor(t *testing.T, consumer turing.Consumer, runnable turing.Runnable, topic string) *recordingProcessor {
+	rp := &recordingProcessor{}
+	sp, err := turing.NewSimpleProcessor(consumer, runnable, []turing.SimpleProcessorTopicDefinition{
+		{
+			Name: topic,
+			Codec: new(turing.StringCodec),
+			Handler: func (ctx turing.SimpleProcessorContext, msg turing.DecodedKV) (error, bool) {
+				rp.mutex.Lock()
+				rp.values = append(rp.values, msg.Value.(string))
+				rp.mutex.Unlock()
+				return nil, true
+			},
+		},
+	})
+	assert.Nil(t, err)
+	rp.sp = sp
+	go sp.Run()
+	return rp
+}
+
+func TestEndToEndWithCommittedOffsets(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 3)
+	producer := broker.NewProducer()
+
+	rp := startProcessor(t, broker.NewConsumer("group"), "topic")
+	for _, v := range []string{ "a", "b", "c", "d" } {
+		producer.Send("topic", []byte(v), []byte(v))
+	}
+	assert.Eventually(t, func () bool {
+		return len(rp.received()) == 4
+	}, time.Second, 5 * time.Millisecond)
+	rp.sp.Close()
+
+	committed := int64(0)
+	for p := int64(0); p < 3; p++ {
+		off, _ := broker.Committed("group", "topic", p)
+		committed += off
+	}
+	assert.Equal(t, int64(4), committed)
+
+	producer.Send("topic", []byte("e"), []byte("e"))
+	rp = startProcessor(t, broker.NewConsumer("group"), "topic")
+	defer rp.sp.Close()
+	assert.Eventually(t, func () bool {
+		return len(rp.received()) == 1
+	}, time.Second, 5 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, []string{ "e" }, rp.received())
+}
+
+func TestConsumerGroupRebalance(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 4)
+
+	first := broker.NewConsumer("group")
+	second := broker.NewConsumer("group")
+	first.Subscribe([]string{ "topic" })
+	assert.Len(t, first.Assignment(), 4)
+
+	second.Subscribe([]string{ "topic" })
+	assert.Equal(t, []TopicPartition{ { "topic", 0 }, { "topic", 1 } }, first.Assignment())
+	assert.Equal(t, []TopicPartition{ { "topic", 2 }, { "topic", 3 } }, second.Assignment())
+
+	other := broker.NewConsumer("")
+	other.Subscribe([]string{ "topic" })
+	assert.Len(t, other.Assignment(), 4)
+
+	second.Close()
+	assert.Len(t, first.Assignment(), 4)
+}
+
+func TestAssignOffsets(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 1)
+	producer := broker.NewProducer()
+	for _, v := range []string{ "a", "b", "c" } {
+		producer.Send("topic", nil, []byte(v))
+	}
+
+	consume := func (offset int64) []string {
+		consumer := broker.NewConsumer("")
+		defer consumer.Close()
+		consumer.Subscribe([]string{ "topic" })
+		go consumer.Run()
+
+		ev := <- consumer.PartitionEvent()
+		assert.Equal(t, turing.PartitionCreated, ev.Type)
+		consumer.Assign(ev.Topic, ev.Id, offset)
+
+		var values []string
+		for {
+			select {
+			case msg := <- consumer.MessageEvent():
+				values = append(values, string(msg.Value))
+			case <- time.After(20 * time.Millisecond):
+				return values
+			}
+		}
+	}
+
+	assert.Equal(t, []string{ "a", "b", "c" }, consume(turing.OffsetEarliest))
+	assert.Equal(t, []string{ "a", "b", "c" }, consume(turing.OffsetStored))
+	assert.Equal(t, []string{ "b", "c" }, consume(1))
+	assert.Nil(t, consume(turing.OffsetLatest))
+}
+
+func TestConsumerTester(t *testing.T) {
+	ct := NewConsumerTester([]TopicDescription{
+		{ Name: "topic", Partitions: 2, Codec: new(turing.StringCodec) },
+	})
+	assert.Equal(t, turing.TopicNotExistsError, ct.SendMessage("missing", "k", "v"))
+	assert.Nil(t, ct.SendMessage("topic", "k", "v"))
+
+	partition := PartitionForKey([]byte("k"), 2)
+	messages := ct.Broker().Messages("topic", partition)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "v", string(messages[0].Value))
+}
+func TestRevokeAndReassign(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 4)
+
+	first := broker.NewConsumer("group")
+	second := broker.NewConsumer("group")
+	first.Subscribe([]string{ "topic" })
+	second.Subscribe([]string{ "topic" })
+
+	broker.Revoke("group", "topic", 1)
+	assert.Equal(t, []TopicPartition{ { "topic", 0 } }, first.Assignment())
+	assert.Equal(t, []TopicPartition{ { "topic", 2 }, { "topic", 3 } }, second.Assignment())
+
+	broker.Reassign("topic", 1, second)
+	broker.Reassign("topic", 2, first)
+	assert.Equal(t, []TopicPartition{ { "topic", 0 }, { "topic", 2 } }, first.Assignment())
+	assert.Equal(t, []TopicPartition{ { "topic", 1 }, { "topic", 3 } }, second.Assignment())
+
+	third := broker.NewConsumer("group")
+	third.Subscribe([]string{ "topic" })
+	assert.Equal(t, []TopicPartition{ { "topic", 0 }, { "topic", 2 } }, first.Assignment())
+	assert.Equal(t, []TopicPartition{ { "topic", 1 }, { "topic", 3 } }, second.Assignment())
+	assert.Empty(t, third.Assignment())
+
+	broker.Rebalance("group")
+	assert.Equal(t, []TopicPartition{ { "topic", 0 }, { "topic", 1 } }, first.Assignment())
+	assert.Equal(t, []TopicPartition{ { "topic", 2 } }, second.Assignment())
+	assert.Equal(t, []TopicPartition{ { "topic", 3 } }, third.Assignment())
+}
+
+func TestRebalanceEvents(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 2)
+	consumer := broker.NewConsumer("group")
+	defer consumer.Close()
+	consumer.Subscribe([]string{ "topic" })
+	go consumer.Run()
+
+	next := func () turing.PartitionEvent {
+		select {
+		case ev := <- consumer.PartitionEvent():
+			return ev
+		case <- time.After(time.Second):
+			t.Fatal("no partition event")
+			return turing.PartitionEvent{}
+		}
+	}
+
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionCreated, Topic: "topic", Id: 0 }, next())
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionCreated, Topic: "topic", Id: 1 }, next())
+
+	broker.Revoke("group", "topic", 1)
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionDestroyed, Topic: "topic", Id: 1 }, next())
+	broker.Reassign("topic", 1, consumer)
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionCreated, Topic: "topic", Id: 1 }, next())
+
+	broker.Rebalance("group")
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionDestroyed, Topic: "topic", Id: 0 }, next())
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionDestroyed, Topic: "topic", Id: 1 }, next())
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionCreated, Topic: "topic", Id: 0 }, next())
+	assert.Equal(t, turing.PartitionEvent{ Type: turing.PartitionCreated, Topic: "topic", Id: 1 }, next())
+}
+
+func TestProcessorsShareGroup(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 2)
+	producer := broker.NewProducer()
+
+	firstConsumer := broker.NewConsumer("group")
+	secondConsumer := broker.NewConsumer("group")
+	first := startProcessor(t, firstConsumer, "topic")
+	defer first.sp.Close()
+	second := startProcessor(t, secondConsumer, "topic")
+	defer second.sp.Close()
+
+	producer.Send("topic", nil, []byte("a"))
+	producer.Send("topic", nil, []byte("b"))
+	assert.Eventually(t, func () bool {
+		return len(first.received()) == 1 && len(second.received()) == 1
+	}, time.Second, 5 * time.Millisecond)
+
+	assert.Eventually(t, func () bool {
+		off, _ := broker.Committed("group", "topic", 1)
+		return off == 1
+	}, time.Second, 5 * time.Millisecond)
+	broker.Reassign("topic", 1, firstConsumer)
+	assert.Eventually(t, func () bool {
+		return len(firstConsumer.Assignment()) == 2 && len(secondConsumer.Assignment()) == 0
+	}, time.Second, 5 * time.Millisecond)
+
+	producer.Send("topic", nil, []byte("c"))
+	producer.Send("topic", nil, []byte("d"))
+	assert.Eventually(t, func () bool {
+		return len(first.received()) == 3
+	}, time.Second, 5 * time.Millisecond)
+	assert.Len(t, second.received(), 1)
+}
+
+func TestPartitionEnd(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 1)
+	producer := broker.NewProducer()
+	producer.Send("topic", nil, []byte("a"))
+
+	consumer := broker.NewConsumer("")
+	defer consumer.Close()
+	consumer.SetPartitionEOF(true)
+	consumer.Subscribe([]string{ "topic" })
+	go consumer.Run()
+
+	ev := <- consumer.PartitionEvent()
+	consumer.Assign(ev.Topic, ev.Id, turing.OffsetEarliest)
+
+	expectEnd := func () {
+		select {
+		case ev := <- consumer.PartitionEvent():
+			assert.Equal(t, turing.PartitionEnd, ev.Type)
+		case <- time.After(time.Second):
+			t.Fatal("no partition end event")
+		}
+	}
+
+	msg := <- consumer.MessageEvent()
+	assert.Equal(t, "a", string(msg.Value))
+	expectEnd()
+
+	select {
+	case <- consumer.PartitionEvent():
+		t.Fatal("partition end should be emitted once")
+	case <- time.After(20 * time.Millisecond):
+	}
+
+	producer.Send("topic", nil, []byte("b"))
+	msg = <- consumer.MessageEvent()
+	assert.Equal(t, "b", string(msg.Value))
+	expectEnd()
+}
+
+func TestInjectedFailures(t *testing.T) {
+	broker := NewBroker()
+	broker.CreateTopic("topic", 1)
+
+	producer := broker.NewProducer()
+	producer.FailNextSends(1, turing.ConnectionDroppedError)
+	assert.Equal(t, turing.ConnectionDroppedError, producer.Send("topic", nil, []byte("a")))
+	assert.Nil(t, producer.Send("topic", nil, []byte("a")))
+	assert.Equal(t, int64(1), broker.HighWatermark("topic", 0))
+
+	consumer := broker.NewConsumer("group")
+	consumer.Subscribe([]string{ "topic" })
+	consumer.FailNextCommits(1)
+	consumer.Commit("topic", 0, 0)
+	_, ok := broker.Committed("group", "topic", 0)
+	assert.False(t, ok)
+
+	consumer.Commit("topic", 0, 0)
+	off, _ := broker.Committed("group", "topic", 0)
+	assert.Equal(t, int64(1), off)
+}
\ No newline at end of file
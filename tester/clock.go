@@ -0,0 +1,205 @@
+package tester
+
+import (
+	"sync"
+	"time"
+	"github.com/areller/turing"
+)
+
+const defaultSettleTime = time.Millisecond
+
+type virtualTimer struct {
+	clock *VirtualClock
+	c chan time.Time
+	at time.Time
+	seq uint64
+	armed bool
+	cancel chan struct{}
+}
+
+func (vt *virtualTimer) C() <-chan time.Time {
+	return vt.c
+}
+
+func (vt *virtualTimer) Stop() bool {
+	vt.clock.mutex.Lock()
+	defer vt.clock.mutex.Unlock()
+	return vt.clock.stopLocked(vt)
+}
+
+func (vt *virtualTimer) Reset(d time.Duration) bool {
+	vt.clock.mutex.Lock()
+	defer vt.clock.mutex.Unlock()
+	wasArmed := vt.clock.stopLocked(vt)
+	vt.clock.armLocked(vt, d)
+	return wasArmed
+}
+
+// VirtualClock is a turing.Clock whose time only moves on Advance. Timers
+// fire in order of their deadline. A tick is handed over once it is
+// received, or dropped when its timer is stopped or re-armed first, and
+// Advance then lets the clock settle before firing the next one: it waits
+// until no timer has been created, armed or stopped for the settle time, so
+// that the work the tick triggered is waiting on the clock again.
+type VirtualClock struct {
+	now time.Time
+	timers map[*virtualTimer]struct{}
+	seq uint64
+	activity uint64
+	settle time.Duration
+	cond *sync.Cond
+	mutex sync.Mutex
+}
+
+func (vc *VirtualClock) armLocked(vt *virtualTimer, d time.Duration) {
+	vc.seq++
+	vt.seq = vc.seq
+	vt.at = vc.now.Add(d)
+	vt.armed = true
+	vc.timers[vt] = struct{}{}
+	vc.activity++
+	vc.cond.Broadcast()
+}
+
+// stopLocked disarms vt and drops a tick that is being delivered but was not
+// received yet.
+func (vc *VirtualClock) stopLocked(vt *virtualTimer) bool {
+	wasArmed := vt.armed
+	vt.armed = false
+	delete(vc.timers, vt)
+
+	if vt.cancel != nil {
+		close(vt.cancel)
+		vt.cancel = nil
+	}
+	vc.activity++
+	return wasArmed
+}
+
+// deliverLocked hands a tick of vt over, it returns once the tick was
+// received or dropped.
+func (vc *VirtualClock) deliverLocked(vt *virtualTimer) {
+	cancel := make(chan struct{})
+	vt.cancel = cancel
+	now := vc.now
+
+	vc.mutex.Unlock()
+	select {
+	case vt.c <- now:
+	case <- cancel:
+	}
+	vc.mutex.Lock()
+
+	if vt.cancel == cancel {
+		vt.cancel = nil
+	}
+}
+
+// settleLocked waits until the clock has not been used for the settle time.
+func (vc *VirtualClock) settleLocked() {
+	for {
+		seen := vc.activity
+		vc.mutex.Unlock()
+		time.Sleep(vc.settle)
+		vc.mutex.Lock()
+		if vc.activity == seen {
+			return
+		}
+	}
+}
+
+func (vc *VirtualClock) nextLocked(until time.Time) *virtualTimer {
+	var next *virtualTimer
+	for vt := range vc.timers {
+		if vt.at.After(until) {
+			continue
+		}
+		if next == nil || vt.at.Before(next.at) || (vt.at.Equal(next.at) && vt.seq < next.seq) {
+			next = vt
+		}
+	}
+	return next
+}
+
+func (vc *VirtualClock) Now() time.Time {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	return vc.now
+}
+
+func (vc *VirtualClock) NewTimer(d time.Duration) turing.Timer {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	vt := &virtualTimer{
+		clock: vc,
+		c: make(chan time.Time),
+	}
+	vc.armLocked(vt, d)
+	return vt
+}
+
+// SetSettleTime sets how long the clock must go unused after a tick before
+// Advance moves on, it should exceed the time the components under test take
+// to react to a tick.
+func (vc *VirtualClock) SetSettleTime(settle time.Duration) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	vc.settle = settle
+}
+
+// Timers returns the number of armed timers.
+func (vc *VirtualClock) Timers() int {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	return len(vc.timers)
+}
+
+// WaitForTimers blocks until at least n timers are armed, which is how a
+// test knows that a component running in another goroutine is waiting on
+// the clock.
+func (vc *VirtualClock) WaitForTimers(n int) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	for len(vc.timers) < n {
+		vc.cond.Wait()
+	}
+}
+
+func (vc *VirtualClock) Advance(d time.Duration) {
+	vc.AdvanceTo(vc.Now().Add(d))
+}
+
+// AdvanceTo fires every timer due until t, moving the time to each deadline
+// in turn, and returns once the clock has settled after the last tick.
+func (vc *VirtualClock) AdvanceTo(t time.Time) {
+	vc.mutex.Lock()
+	defer vc.mutex.Unlock()
+	for {
+		vt := vc.nextLocked(t)
+		if vt == nil {
+			break
+		}
+
+		if vt.at.After(vc.now) {
+			vc.now = vt.at
+		}
+		vt.armed = false
+		delete(vc.timers, vt)
+		vc.deliverLocked(vt)
+		vc.settleLocked()
+	}
+
+	if t.After(vc.now) {
+		vc.now = t
+	}
+}
+
+func NewVirtualClock(start time.Time) *VirtualClock {
+	vc := &VirtualClock{
+		now: start,
+		timers: make(map[*virtualTimer]struct{}),
+		settle: defaultSettleTime,
+	}
+	vc.cond = sync.NewCond(&vc.mutex)
+	return vc
+}
\ No newline at end of file
@@ -0,0 +1,248 @@
+package tester
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+// timeLog records times from the goroutines a clock wakes up.
+type timeLog struct {
+	times []time.Time
+	mutex sync.Mutex
+}
+
+func (tl *timeLog) add(at time.Time) int {
+	tl.mutex.Lock()
+	defer tl.mutex.Unlock()
+	tl.times = append(tl.times, at)
+	return len(tl.times)
+}
+
+func (tl *timeLog) get() []time.Time {
+	tl.mutex.Lock()
+	defer tl.mutex.Unlock()
+	return append([]time.Time(nil), tl.times...)
+}
+
+func (tl *timeLog) since(start time.Time) []time.Duration {
+	var durations []time.Duration
+	for _, at := range tl.get() {
+		durations = append(durations, at.Sub(start))
+	}
+	return durations
+}
+
+func TestVirtualClockTimers(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := NewVirtualClock(start)
+
+	fired := &timeLog{}
+	received := func (timer turing.Timer) {
+		go func () {
+			fired.add(<- timer.C())
+		}()
+	}
+
+	late := clock.NewTimer(2 * time.Second)
+	early := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	received(late)
+	received(early)
+	assert.True(t, stopped.Stop())
+	assert.Equal(t, 2, clock.Timers())
+
+	clock.Advance(1500 * time.Millisecond)
+	assert.Equal(t, []time.Duration{ time.Second }, fired.since(start))
+	assert.Equal(t, start.Add(1500 * time.Millisecond), clock.Now())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, []time.Duration{ time.Second, 2 * time.Second }, fired.since(start))
+	assert.Equal(t, 0, clock.Timers())
+	assert.False(t, late.Stop())
+
+	late.Reset(time.Second)
+	received(late)
+	clock.AdvanceTo(start.Add(time.Hour))
+	assert.Equal(t, []time.Duration{ time.Second, 2 * time.Second, 3500 * time.Millisecond }, fired.since(start))
+}
+
+func TestVirtualClockTicker(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+	ticks := &timeLog{}
+	ticker := turing.NewTicker(time.Minute, nil, func (obj interface{}) (error, bool) {
+		ticks.add(clock.Now())
+		return nil, true
+	})
+	ticker.SetClock(clock)
+	go ticker.Run()
+	defer ticker.Close()
+	clock.WaitForTimers(1)
+
+	clock.Advance(59 * time.Second)
+	assert.Empty(t, ticks.get())
+	clock.Advance(time.Hour + time.Second)
+	assert.Len(t, ticks.get(), 61)
+	assert.Equal(t, time.Unix(60, 0), ticks.get()[0])
+	assert.Equal(t, time.Unix(3660, 0), ticks.get()[60])
+}
+
+func TestVirtualClockTickerRetry(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+	attempts := &timeLog{}
+	ticker := turing.NewTicker(time.Minute, nil, func (obj interface{}) (error, bool) {
+		return nil, attempts.add(clock.Now()) % 3 == 0
+	})
+	ticker.SetClock(clock)
+	ticker.SetRetryDelay(10 * time.Second)
+	go ticker.Run()
+	defer ticker.Close()
+	clock.WaitForTimers(1)
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, []time.Time{
+		time.Unix(60, 0),
+		time.Unix(70, 0),
+		time.Unix(80, 0),
+		time.Unix(120, 0),
+	}, attempts.get())
+
+	clock.Advance(10 * time.Second)
+	assert.Len(t, attempts.get(), 5)
+	assert.Equal(t, time.Unix(130, 0), attempts.get()[4])
+}
+
+func TestVirtualClockMemoryStore(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+	store := turing.NewKVStoreMemory(turing.WithMemoryClock(clock))
+	defer store.Close()
+
+	assert.Nil(t, store.Set("key", "value"))
+	assert.Nil(t, store.Expire("key", time.Minute))
+	clock.Advance(59 * time.Second)
+	n, _ := store.Exists("key")
+	assert.Equal(t, 1, n)
+
+	clock.Advance(time.Second)
+	n, _ = store.Exists("key")
+	assert.Equal(t, 0, n)
+}
+
+func TestVirtualClockLeaderElection(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+	store := turing.NewKVStoreMemory(turing.WithMemoryClock(clock))
+	defer store.Close()
+	timers := clock.Timers()
+
+	var electors []*turing.LeaderElector
+	for i := 0; i < 2; i++ {
+		elector := turing.NewLeaderElector(store, "leader", 30 * time.Second)
+		elector.SetClock(clock)
+		electors = append(electors, elector)
+		go elector.Run()
+		defer elector.Close()
+		clock.WaitForTimers(timers + i + 1)
+		assert.Eventually(t, electors[0].IsLeader, time.Second, time.Millisecond)
+	}
+
+	// Renewals on virtual time keep the first elector in charge.
+	for i := 0; i < 36; i++ {
+		clock.Advance(10 * time.Second)
+	}
+	assert.True(t, electors[0].IsLeader())
+	assert.False(t, electors[1].IsLeader())
+
+	lock := turing.NewLock(store, "lock", time.Minute)
+	lock.SetClock(clock)
+	ok, err := lock.TryAcquire()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	clock.Advance(59 * time.Second)
+	assert.True(t, lock.Held())
+	clock.Advance(time.Second)
+	assert.False(t, lock.Held())
+}
+
+type failingRunnable struct {
+}
+
+func (fr failingRunnable) Run() error {
+	return turing.GeneralError
+}
+
+func (fr failingRunnable) Close() {
+}
+
+func TestVirtualClockSupervisor(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+	starts := make(chan time.Time, 10)
+	supervisor := turing.NewSupervisor("test", turing.OneForOne, []turing.SupervisorChild{
+		{
+			Name: "child",
+			Factory: func () (turing.Runnable, error) {
+				starts <- clock.Now()
+				return failingRunnable{}, nil
+			},
+		},
+	})
+	supervisor.SetClock(clock)
+	supervisor.SetBackoff(time.Second, time.Second)
+	supervisor.SetRestartIntensity(100, time.Minute)
+	go supervisor.Run()
+	defer supervisor.Close()
+
+	assert.Equal(t, time.Unix(0, 0), <- starts)
+	for i := 1; i <= 5; i++ {
+		clock.WaitForTimers(1)
+		clock.Advance(time.Second)
+		assert.Equal(t, time.Unix(int64(i), 0), <- starts)
+	}
+}
+
+func TestVirtualClockStuckPartition(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(1000, 0))
+	handling := make(chan struct{})
+	release := make(chan struct{})
+	p := turing.NewPartition("topic", 0)
+	p.SetClock(clock)
+	p.SetCodec(new(turing.StringCodec))
+	p.SetHandler(func (p *turing.Partition, original turing.EncodedKV, message turing.DecodedKV) {
+		close(handling)
+		<- release
+	})
+	go p.Run()
+	defer p.Close()
+
+	p.Messages <- turing.MessageEvent{ Key: []byte("k"), Value: []byte("v") }
+	<- handling
+	assert.False(t, p.Stuck(time.Minute))
+	clock.Advance(2 * time.Minute)
+	assert.True(t, p.Stuck(time.Minute))
+	close(release)
+}
+
+func TestVirtualClockCircuitBreaker(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+	backing := turing.NewKVStoreMemory()
+	defer backing.Close()
+	faulty := NewFaultyKVStore(backing)
+	store := turing.NewResilientKVStore(faulty)
+	store.SetClock(clock)
+	store.SetRetries(0, time.Second, time.Second)
+	store.SetCircuitBreaker(1, time.Minute)
+
+	faulty.Disconnect()
+	assert.Equal(t, turing.ConnectionDroppedError, store.Set("a", "1"))
+	assert.Equal(t, turing.CircuitOpen, store.State())
+
+	clock.Advance(59 * time.Second)
+	assert.Equal(t, turing.CircuitOpenError, store.Set("a", "1"))
+	clock.Advance(time.Second)
+	assert.Equal(t, turing.CircuitHalfOpen, store.State())
+
+	faulty.Reconnect()
+	assert.Nil(t, store.Set("a", "1"))
+	assert.Equal(t, turing.CircuitClosed, store.State())
+}
\ No newline at end of file
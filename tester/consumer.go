@@ -1,113 +1,340 @@
 package tester
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"github.com/areller/turing"
 )
 
-type activePartition struct {
-	id int
-	currentOffset int64
+var anonymousGroups int64
+
+type partitionPosition struct {
+	assigned bool
+	offset int64
+	eof bool
 }
 
-type activeTopic struct {
-	totalMessages int64
-	partitions map[int]*activePartition
-	numPartitions int
-	def TopicDescription
+// Consumer reads from a Broker as a member of a consumer group. Partitions
+// are announced with PartitionCreated/PartitionDestroyed events and nothing
+// is delivered from a partition until Assign has been called for it.
+type Consumer struct {
+	broker *Broker
+	group string
+	topics []string
+	positions map[TopicPartition]*partitionPosition
+	order []TopicPartition
+	cursor int
+	pending []turing.PartitionEvent
+	autoOffsetReset int64
+	partitionEOF bool
+	failCommits int
+	partitionsChan chan turing.PartitionEvent
+	messagesChan chan turing.MessageEvent
+	wake chan struct{}
+	closeChan chan struct{}
+	closeOnce sync.Once
 }
 
-type TopicDescription struct {
-	Name string
-	Partitions int
-	Codec turing.Codec
+func (c *Consumer) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
 }
 
-type ConsumerTester struct {
-	topics map[string]TopicDescription
-	partitionsChan chan turing.PartitionEvent
-	messagesChan chan turing.MessageEvent
-	activeTopics map[string]*activeTopic
+func (c *Consumer) setAssignmentLocked(tps []TopicPartition) {
+	next := make(map[TopicPartition]bool)
+	for _, tp := range tps {
+		next[tp] = true
+	}
+
+	var revoked []TopicPartition
+	for tp := range c.positions {
+		if !next[tp] {
+			revoked = append(revoked, tp)
+		}
+	}
+	sortTopicPartitions(revoked)
+	for _, tp := range revoked {
+		delete(c.positions, tp)
+		c.pending = append(c.pending, turing.PartitionEvent{
+			Type: turing.PartitionDestroyed,
+			Topic: tp.Topic,
+			Id: tp.Partition,
+		})
+	}
+
+	var added []TopicPartition
+	for _, tp := range tps {
+		if _, ok := c.positions[tp]; !ok {
+			added = append(added, tp)
+		}
+	}
+	sortTopicPartitions(added)
+	for _, tp := range added {
+		c.positions[tp] = &partitionPosition{}
+		c.pending = append(c.pending, turing.PartitionEvent{
+			Type: turing.PartitionCreated,
+			Topic: tp.Topic,
+			Id: tp.Partition,
+		})
+	}
+
+	c.order = c.order[:0]
+	for tp := range c.positions {
+		c.order = append(c.order, tp)
+	}
+	sortTopicPartitions(c.order)
+	c.signal()
 }
 
-func (ct *ConsumerTester) Assign(topic string, partition int64, offset int64) { }
+func (c *Consumer) SetAutoOffsetReset(offset int64) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	c.autoOffsetReset = offset
+}
 
-func (ct *ConsumerTester) Commit(topic string, partition int64, offset int64) { }
+func (c *Consumer) SetPartitionEOF(enabled bool) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	c.partitionEOF = enabled
+	c.signal()
+}
 
-func (ct *ConsumerTester) Subscribe(topics []string) {
-	for _, topicName := range topics {
-		definedTopic, ok := ct.topics[topicName]
-		if !ok {
-			continue
+func (c *Consumer) FailNextCommits(n int) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	c.failCommits = n
+}
+
+func (c *Consumer) Group() string {
+	return c.group
+}
+
+func (c *Consumer) Assignment() []TopicPartition {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	tps := make([]TopicPartition, len(c.order))
+	copy(tps, c.order)
+	return tps
+}
+
+func (c *Consumer) PartitionEvent() <-chan turing.PartitionEvent {
+	return c.partitionsChan
+}
+
+func (c *Consumer) MessageEvent() <-chan turing.MessageEvent {
+	return c.messagesChan
+}
+
+func (c *Consumer) Subscribe(topics []string) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	c.topics = topics
+	c.broker.consumers[c] = struct{}{}
+	c.broker.joinLocked(c)
+	c.broker.rebalanceLocked(c.group)
+}
+
+func (c *Consumer) resetOffsetLocked(tp TopicPartition) int64 {
+	if c.autoOffsetReset == turing.OffsetLatest {
+		return c.broker.highWatermarkLocked(tp)
+	}
+	return 0
+}
+
+func (c *Consumer) Assign(topic string, partition int64, offset int64) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	tp := TopicPartition{ Topic: topic, Partition: partition }
+	pos, ok := c.positions[tp]
+	if !ok {
+		return
+	}
+
+	hw := c.broker.highWatermarkLocked(tp)
+	switch offset {
+	case turing.OffsetEarliest:
+		pos.offset = 0
+	case turing.OffsetLatest:
+		pos.offset = hw
+	case turing.OffsetStored, turing.OffsetNone:
+		if committed, ok := c.broker.groupLocked(c.group).committed[tp]; ok {
+			pos.offset = committed
+		} else {
+			pos.offset = c.resetOffsetLocked(tp)
 		}
+	default:
+		if offset < 0 || offset > hw {
+			offset = c.resetOffsetLocked(tp)
+		}
+		pos.offset = offset
+	}
+
+	pos.assigned = true
+	c.signal()
+	c.broker.changed.Broadcast()
+}
+
+func (c *Consumer) commitFailed(tp TopicPartition, offset int64, reason string) {
+	turing.GetMetrics().CommitFailed(tp.Topic, tp.Partition)
+	turing.Log.WithFields(turing.LogFields{
+		"topic": tp.Topic,
+		"partition": tp.Partition,
+		"offset": offset,
+		"reason": reason,
+	}).Error("tester consumer: could not commit offset")
+}
+
+func (c *Consumer) Commit(topic string, partition int64, offset int64) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	tp := TopicPartition{ Topic: topic, Partition: partition }
+	if _, ok := c.positions[tp]; !ok {
+		c.commitFailed(tp, offset, "partition is not assigned")
+		return
+	} else if c.failCommits > 0 {
+		c.failCommits--
+		c.commitFailed(tp, offset, "failure injected")
+		return
+	}
 
-		partitions := definedTopic.Partitions
-		ct.activeTopics[topicName] = &activeTopic{
-			totalMessages: 0,
-			partitions: make(map[int]*activePartition),
-			numPartitions: partitions,
-			def: definedTopic,
+	c.broker.groupLocked(c.group).committed[tp] = offset + 1
+	c.broker.changed.Broadcast()
+}
+
+func (c *Consumer) next() (*turing.PartitionEvent, *turing.MessageEvent) {
+	c.broker.mutex.Lock()
+	defer c.broker.mutex.Unlock()
+	if len(c.pending) > 0 {
+		ev := c.pending[0]
+		c.pending = c.pending[1:]
+		return &ev, nil
+	}
+
+	for i := 0; i < len(c.order); i++ {
+		idx := (c.cursor + i) % len(c.order)
+		tp := c.order[idx]
+		pos := c.positions[tp]
+		if !pos.assigned {
+			continue
+		} else if pos.offset >= c.broker.highWatermarkLocked(tp) {
+			if c.partitionEOF && !pos.eof {
+				pos.eof = true
+				c.cursor = idx + 1
+				return &turing.PartitionEvent{
+					Type: turing.PartitionEnd,
+					Topic: tp.Topic,
+					Id: tp.Partition,
+				}, nil
+			}
+			continue
 		}
 
-		for i := 0; i < partitions; i++ {
-			ct.activeTopics[topicName].partitions[i] = &activePartition{
-				id: i,
-				currentOffset: 0,
+		msg := c.broker.topics[tp.Topic].partitions[tp.Partition][pos.offset]
+		pos.offset++
+		pos.eof = false
+		c.cursor = idx + 1
+		return nil, &msg
+	}
+
+	return nil, nil
+}
+
+func (c *Consumer) Close() {
+	c.closeOnce.Do(func () {
+		c.broker.mutex.Lock()
+		c.broker.leaveLocked(c)
+		c.broker.mutex.Unlock()
+		close(c.closeChan)
+	})
+}
+
+func (c *Consumer) Run() error {
+	for {
+		ev, msg := c.next()
+		if ev != nil {
+			select {
+			case c.partitionsChan <- *ev:
+			case <- c.closeChan:
+				return nil
 			}
-			ct.partitionsChan <- turing.PartitionEvent{
-				Type: turing.PartitionCreated,
-				Topic: topicName,
-				Id: int64(i),
+		} else if msg != nil {
+			select {
+			case c.messagesChan <- *msg:
+			case <- c.closeChan:
+				return nil
+			}
+		} else {
+			select {
+			case <- c.wake:
+			case <- c.closeChan:
+				return nil
 			}
 		}
 	}
 }
 
-func (ct *ConsumerTester) PartitionEvent() <-chan turing.PartitionEvent {
-	return ct.partitionsChan
+func newConsumer(broker *Broker, group string) *Consumer {
+	if group == "" {
+		group = fmt.Sprintf("tester-anonymous-%d", atomic.AddInt64(&anonymousGroups, 1))
+	}
+
+	return &Consumer{
+		broker: broker,
+		group: group,
+		positions: make(map[TopicPartition]*partitionPosition),
+		autoOffsetReset: turing.OffsetEarliest,
+		partitionsChan: make(chan turing.PartitionEvent),
+		messagesChan: make(chan turing.MessageEvent),
+		wake: make(chan struct{}, 1),
+		closeChan: make(chan struct{}),
+	}
+}
+
+type TopicDescription struct {
+	Name string
+	Partitions int
+	Codec turing.Codec
+}
+
+// ConsumerTester is a single consumer over its own broker that can encode
+// messages with the codecs of the described topics.
+type ConsumerTester struct {
+	*Consumer
+	topics map[string]TopicDescription
 }
 
-func (ct *ConsumerTester) MessageEvent() <-chan turing.MessageEvent {
-	return ct.messagesChan
+func (ct *ConsumerTester) Broker() *Broker {
+	return ct.broker
 }
 
 func (ct *ConsumerTester) SendMessage(topic string, key string, message interface{}) error {
-	activeTopic, ok := ct.activeTopics[topic]
+	def, ok := ct.topics[topic]
 	if !ok {
 		return turing.TopicNotExistsError
 	}
 
-	encoded, err := activeTopic.def.Codec.Encode(key, message)
+	encoded, err := def.Codec.Encode(key, message)
 	if err != nil {
 		return err
 	}
 
-	part := activeTopic.partitions[int(activeTopic.totalMessages) % activeTopic.numPartitions]
-	ct.messagesChan <- turing.MessageEvent{
-		Topic: topic,
-		PartitionId: int64(part.id),
-		Offset: part.currentOffset,
-		Key: encoded.Key,
-		Value: encoded.Value,
-	}
-
-	activeTopic.totalMessages++
-	part.currentOffset++
-	return nil
+	_, _, err = ct.broker.Produce(topic, encoded.Key, encoded.Value, encoded.Headers)
+	return err
 }
 
 func NewConsumerTester(topics []TopicDescription) *ConsumerTester {
+	broker := NewBroker()
 	topicsMap := make(map[string]TopicDescription)
-	buffer := 0
-
 	for _, topic := range topics {
 		topicsMap[topic.Name] = topic
-		buffer += topic.Partitions
+		broker.CreateTopic(topic.Name, topic.Partitions)
 	}
 
 	return &ConsumerTester{
+		Consumer: broker.NewConsumer("tester"),
 		topics: topicsMap,
-		partitionsChan: make(chan turing.PartitionEvent, buffer),
-		messagesChan: make(chan turing.MessageEvent, 1),
-		activeTopics: make(map[string]*activeTopic),
 	}
 }
\ No newline at end of file
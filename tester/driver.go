@@ -0,0 +1,191 @@
+package tester
+
+import (
+	"sync"
+	"time"
+	"github.com/areller/turing"
+)
+
+const driverGroup = "driver"
+
+// Driver runs a SimpleProcessor over single partition topics on a virtual
+// clock. Pipe returns once the processor has handled the record, so the
+// output it produced can be read right away. Completion is detected through
+// commits, the processor must keep its default commit behavior. A record that
+// is not committed within the pipe timeout, because its handler failed or did
+// not move on, makes Pipe fail with turing.NotProcessedError.
+type Driver struct {
+	broker *Broker
+	consumer *Consumer
+	clock *VirtualClock
+	producer *turing.ProducerMock
+	read map[string]int
+	readMutex sync.Mutex
+	codecs map[string]turing.Codec
+	processor *turing.SimpleProcessor
+	tickers []*turing.Ticker
+	pipeTimeout time.Duration
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+func (d *Driver) Clock() *VirtualClock {
+	return d.clock
+}
+
+// Producer returns a producer on the driver's clock, handlers send their
+// output to it.
+func (d *Driver) Producer() *turing.ProducerMock {
+	return d.producer
+}
+
+func (d *Driver) Processor() *turing.SimpleProcessor {
+	return d.processor
+}
+
+func (d *Driver) Broker() *Broker {
+	return d.broker
+}
+
+// SetPipeTimeout sets how long, in real time, Pipe waits for a record to be
+// processed.
+func (d *Driver) SetPipeTimeout(timeout time.Duration) {
+	d.pipeTimeout = timeout
+}
+
+func (d *Driver) NewKVStoreMemory(opts ...turing.KVStoreMemoryOption) *turing.KVStoreMemory {
+	return turing.NewKVStoreMemory(append([]turing.KVStoreMemoryOption{ turing.WithMemoryClock(d.clock) }, opts...)...)
+}
+
+// RunTicker starts ticker on the driver's clock and returns once it is
+// waiting for its first tick. The ticker is closed with the driver.
+func (d *Driver) RunTicker(ticker *turing.Ticker) {
+	timers := d.clock.Timers()
+	ticker.SetClock(d.clock)
+	d.tickers = append(d.tickers, ticker)
+	go ticker.Run()
+	d.clock.WaitForTimers(timers + 1)
+}
+
+// Start runs the processor and waits until all of its partitions have been
+// assigned. It is called by the first Pipe if needed.
+func (d *Driver) Start() {
+	d.startOnce.Do(func () {
+		go d.processor.Run()
+
+		d.broker.mutex.Lock()
+		defer d.broker.mutex.Unlock()
+		for {
+			assigned := 0
+			for _, pos := range d.consumer.positions {
+				if pos.assigned {
+					assigned++
+				}
+			}
+			if assigned == len(d.codecs) {
+				return
+			}
+			d.broker.changed.Wait()
+		}
+	})
+}
+
+func (d *Driver) PipeWithHeaders(topic string, key string, value interface{}, headers []turing.Header) error {
+	codec, ok := d.codecs[topic]
+	if !ok {
+		return turing.TopicNotExistsError
+	}
+
+	encoded, err := codec.Encode(key, value)
+	if err != nil {
+		return err
+	}
+
+	d.Start()
+	_, offset, err := d.broker.Produce(topic, encoded.Key, encoded.Value, append(encoded.Headers, headers...))
+	if err != nil {
+		return err
+	}
+
+	expired := false
+	timer := time.AfterFunc(d.pipeTimeout, func () {
+		d.broker.mutex.Lock()
+		defer d.broker.mutex.Unlock()
+		expired = true
+		d.broker.changed.Broadcast()
+	})
+	defer timer.Stop()
+
+	d.broker.mutex.Lock()
+	defer d.broker.mutex.Unlock()
+	tp := TopicPartition{ Topic: topic, Partition: 0 }
+	for {
+		if committed, ok := d.broker.groupLocked(driverGroup).committed[tp]; ok && committed > offset {
+			return nil
+		} else if expired {
+			return turing.NotProcessedError
+		}
+		d.broker.changed.Wait()
+	}
+}
+
+func (d *Driver) Pipe(topic string, key string, value interface{}) error {
+	return d.PipeWithHeaders(topic, key, value, nil)
+}
+
+func (d *Driver) Advance(dur time.Duration) {
+	d.clock.Advance(dur)
+}
+
+// ReadOutput returns the messages sent to topic since it was last read.
+func (d *Driver) ReadOutput(topic string) []turing.SentMessage {
+	d.readMutex.Lock()
+	defer d.readMutex.Unlock()
+	sent := d.producer.MessagesTo(topic)
+	read := d.read[topic]
+	if read > len(sent) {
+		// The producer was reset.
+		read = 0
+	}
+	d.read[topic] = len(sent)
+	return sent[read:]
+}
+
+func (d *Driver) Close() {
+	d.closeOnce.Do(func () {
+		for _, ticker := range d.tickers {
+			ticker.Close()
+		}
+		d.processor.Close()
+	})
+}
+
+func NewDriver(start time.Time, topics []turing.SimpleProcessorTopicDefinition) (*Driver, error) {
+	broker := NewBroker()
+	codecs := make(map[string]turing.Codec)
+	for _, topic := range topics {
+		broker.CreateTopic(topic.Name, 1)
+		codecs[topic.Name] = topic.Codec
+	}
+
+	consumer := broker.NewConsumer(driverGroup)
+	processor, err := turing.NewSimpleProcessor(consumer, consumer, topics)
+	if err != nil {
+		return nil, err
+	}
+
+	clock := NewVirtualClock(start)
+	processor.SetClock(clock)
+	producer := turing.NewProducerMock()
+	producer.SetClock(clock)
+	return &Driver{
+		broker: broker,
+		consumer: consumer,
+		clock: clock,
+		producer: producer,
+		read: make(map[string]int),
+		codecs: codecs,
+		processor: processor,
+		pipeTimeout: 5 * time.Second,
+	}, nil
+}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package tester
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriverWindowedCount(t *testing.T) {
+	var driver *Driver
+	var store *turing.KVStoreMemory
+	driver, err := NewDriver(time.Unix(0, 0), []turing.SimpleProcessorTopicDefinition{
+		{
+			Name: "clicks",
+			Codec: new(turing.StringCodec),
+			Handler: func (ctx turing.SimpleProcessorContext, msg turing.DecodedKV) (error, bool) {
+				count, _ := store.Get(msg.Key)
+				n, _ := strconv.Atoi(count)
+				store.Set(msg.Key, n + 1)
+				return nil, true
+			},
+		},
+	})
+	assert.Nil(t, err)
+	defer driver.Close()
+	store = driver.NewKVStoreMemory()
+	defer store.Close()
+
+	driver.RunTicker(turing.NewTicker(time.Minute, nil, func (obj interface{}) (error, bool) {
+		for _, user := range []string{ "alice", "bob" } {
+			if count, err := store.Get(user); err == nil {
+				driver.Producer().Send("counts", []byte(user), []byte(count))
+			}
+		}
+		store.Delete("alice", "bob")
+		return nil, true
+	}))
+
+	assert.Nil(t, driver.Pipe("clicks", "alice", "home"))
+	assert.Nil(t, driver.Pipe("clicks", "alice", "cart"))
+	assert.Nil(t, driver.Pipe("clicks", "bob", "home"))
+	assert.Empty(t, driver.ReadOutput("counts"))
+
+	driver.Advance(time.Minute)
+	out := driver.ReadOutput("counts")
+	assert.Len(t, out, 2)
+	assert.Equal(t, "alice", string(out[0].Key))
+	assert.Equal(t, "2", string(out[0].Value))
+	assert.Equal(t, "1", string(out[1].Value))
+	assert.Equal(t, time.Unix(60, 0), out[0].Time)
+
+	assert.Nil(t, driver.Pipe("clicks", "bob", "home"))
+	driver.Advance(time.Minute)
+	out = driver.ReadOutput("counts")
+	assert.Len(t, out, 1)
+	assert.Equal(t, "bob", string(out[0].Key))
+	assert.Empty(t, driver.ReadOutput("counts"))
+
+	assert.Equal(t, turing.TopicNotExistsError, driver.Pipe("missing", "k", "v"))
+}
+
+func TestDriverOutputFromHandler(t *testing.T) {
+	var driver *Driver
+	driver, err := NewDriver(time.Unix(0, 0), []turing.SimpleProcessorTopicDefinition{
+		{
+			Name: "input",
+			Codec: new(turing.StringCodec),
+			Handler: func (ctx turing.SimpleProcessorContext, msg turing.DecodedKV) (error, bool) {
+				driver.Producer().Send("output", []byte(msg.Key), []byte(msg.Value.(string) + "!"))
+				return nil, true
+			},
+		},
+	})
+	assert.Nil(t, err)
+	defer driver.Close()
+
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, driver.Pipe("input", strconv.Itoa(i), "v" + strconv.Itoa(i)))
+		out := driver.ReadOutput("output")
+		assert.Len(t, out, 1)
+		assert.Equal(t, "v" + strconv.Itoa(i) + "!", string(out[0].Value))
+	}
+}
+
+func TestDriverPipeTimeout(t *testing.T) {
+	failing := int32(1)
+	driver, err := NewDriver(time.Unix(0, 0), []turing.SimpleProcessorTopicDefinition{
+		{
+			Name: "input",
+			Codec: new(turing.StringCodec),
+			Handler: func (ctx turing.SimpleProcessorContext, msg turing.DecodedKV) (error, bool) {
+				if atomic.LoadInt32(&failing) == 1 {
+					return turing.GeneralError, false
+				}
+				return nil, true
+			},
+		},
+	})
+	assert.Nil(t, err)
+	defer driver.Close()
+	driver.SetPipeTimeout(50 * time.Millisecond)
+
+	assert.Equal(t, turing.NotProcessedError, driver.Pipe("input", "k", "v"))
+
+	// The record is retried until the handler moves on.
+	atomic.StoreInt32(&failing, 0)
+	assert.Nil(t, driver.Pipe("input", "k", "v"))
+}
\ No newline at end of file
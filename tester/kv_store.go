@@ -0,0 +1,142 @@
+package tester
+
+import (
+	"sync"
+	"time"
+	"github.com/areller/turing"
+)
+
+// FaultyKVStore wraps a KVStore and fails its operations with
+// turing.ConnectionDroppedError while disconnected or for the next few calls.
+type FaultyKVStore struct {
+	store turing.KVStore
+	disconnected bool
+	failures int
+	calls int
+	mutex sync.Mutex
+}
+
+func (fks *FaultyKVStore) Disconnect() {
+	fks.mutex.Lock()
+	defer fks.mutex.Unlock()
+	fks.disconnected = true
+}
+
+func (fks *FaultyKVStore) Reconnect() {
+	fks.mutex.Lock()
+	defer fks.mutex.Unlock()
+	fks.disconnected = false
+	fks.failures = 0
+}
+
+func (fks *FaultyKVStore) DropNext(n int) {
+	fks.mutex.Lock()
+	defer fks.mutex.Unlock()
+	fks.failures = n
+}
+
+func (fks *FaultyKVStore) Calls() int {
+	fks.mutex.Lock()
+	defer fks.mutex.Unlock()
+	return fks.calls
+}
+
+func (fks *FaultyKVStore) fault() error {
+	fks.mutex.Lock()
+	defer fks.mutex.Unlock()
+	fks.calls++
+	if fks.disconnected {
+		return turing.ConnectionDroppedError
+	} else if fks.failures > 0 {
+		fks.failures--
+		return turing.ConnectionDroppedError
+	}
+	return nil
+}
+
+func (fks *FaultyKVStore) Ping() error {
+	if err := fks.fault(); err != nil {
+		return err
+	}
+
+	if pinger, ok := fks.store.(turing.Pinger); ok {
+		return pinger.Ping()
+	}
+	return nil
+}
+
+func (fks *FaultyKVStore) Set(key string, value interface{}) error {
+	if err := fks.fault(); err != nil {
+		return err
+	}
+	return fks.store.Set(key, value)
+}
+
+func (fks *FaultyKVStore) Get(key string) (string, error) {
+	if err := fks.fault(); err != nil {
+		return "", err
+	}
+	return fks.store.Get(key)
+}
+
+func (fks *FaultyKVStore) Delete(keys ...string) (int, error) {
+	if err := fks.fault(); err != nil {
+		return 0, err
+	}
+	return fks.store.Delete(keys...)
+}
+
+func (fks *FaultyKVStore) Exists(keys ...string) (int, error) {
+	if err := fks.fault(); err != nil {
+		return 0, err
+	}
+	return fks.store.Exists(keys...)
+}
+
+func (fks *FaultyKVStore) HSet(key string, field string, value interface{}) error {
+	if err := fks.fault(); err != nil {
+		return err
+	}
+	return fks.store.HSet(key, field, value)
+}
+
+func (fks *FaultyKVStore) HSetMany(key string, kv map[string]interface{}) error {
+	if err := fks.fault(); err != nil {
+		return err
+	}
+	return fks.store.HSetMany(key, kv)
+}
+
+func (fks *FaultyKVStore) HGet(key string, field string) (string, error) {
+	if err := fks.fault(); err != nil {
+		return "", err
+	}
+	return fks.store.HGet(key, field)
+}
+
+func (fks *FaultyKVStore) HGetAll(key string) (map[string]string, error) {
+	if err := fks.fault(); err != nil {
+		return nil, err
+	}
+	return fks.store.HGetAll(key)
+}
+
+func (fks *FaultyKVStore) HDelete(key string, fields ...string) (int, error) {
+	if err := fks.fault(); err != nil {
+		return 0, err
+	}
+	return fks.store.HDelete(key, fields...)
+}
+
+func (fks *FaultyKVStore) Expire(key string, expiry time.Duration) error {
+	if err := fks.fault(); err != nil {
+		return err
+	}
+	return fks.store.Expire(key, expiry)
+}
+
+func NewFaultyKVStore(store turing.KVStore) *FaultyKVStore {
+	return &FaultyKVStore{
+		store: store,
+	}
+}
\ No newline at end of file
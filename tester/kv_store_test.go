@@ -0,0 +1,42 @@
+package tester
+
+import (
+	"testing"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultyKVStore(t *testing.T) {
+	mem := turing.NewKVStoreMemory()
+	defer mem.Close()
+	store := NewFaultyKVStore(mem)
+
+	assert.Nil(t, store.Set("key", "a"))
+	store.DropNext(2)
+	assert.Equal(t, turing.ConnectionDroppedError, store.Set("key", "b"))
+	_, err := store.Get("key")
+	assert.Equal(t, turing.ConnectionDroppedError, err)
+	value, err := store.Get("key")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", value)
+
+	store.Disconnect()
+	assert.Equal(t, turing.ConnectionDroppedError, store.Ping())
+	assert.Equal(t, turing.ConnectionDroppedError, store.HSet("hash", "field", 1))
+	store.Reconnect()
+	assert.Nil(t, store.Ping())
+	assert.Nil(t, store.HSet("hash", "field", 1))
+	assert.Equal(t, 8, store.Calls())
+}
+
+func TestFaultyKVStoreWithResilientStore(t *testing.T) {
+	mem := turing.NewKVStoreMemory()
+	defer mem.Close()
+	store := NewFaultyKVStore(mem)
+	resilient := turing.NewResilientKVStore(store)
+	resilient.SetRetries(3, 0, 0)
+
+	store.DropNext(2)
+	assert.Nil(t, resilient.HSet("offsets", "topic", 5))
+	assert.Equal(t, 3, store.Calls())
+}
\ No newline at end of file
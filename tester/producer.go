@@ -0,0 +1,52 @@
+package tester
+
+import (
+	"context"
+	"sync"
+	"github.com/areller/turing"
+)
+
+type Producer struct {
+	broker *Broker
+	failures int
+	failureErr error
+	mutex sync.Mutex
+}
+
+func (p *Producer) FailNextSends(n int, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.failures = n
+	p.failureErr = err
+}
+
+func (p *Producer) injectedFailure() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.failures == 0 {
+		return nil
+	}
+	p.failures--
+	return p.failureErr
+}
+
+func (p *Producer) Send(topic string, key []byte, msg []byte) error {
+	return p.SendWithHeaders(topic, key, msg, nil)
+}
+
+func (p *Producer) SendWithHeaders(topic string, key []byte, msg []byte, headers []turing.Header) error {
+	if err := p.injectedFailure(); err != nil {
+		return err
+	}
+
+	_, _, err := p.broker.Produce(topic, key, msg, headers)
+	return err
+}
+
+func (p *Producer) SendContext(ctx context.Context, topic string, key []byte, msg []byte, headers []turing.Header) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return p.SendWithHeaders(topic, key, msg, headers)
+}
\ No newline at end of file
@@ -0,0 +1,594 @@
+package tester
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"github.com/areller/turing"
+)
+
+type RecordFormat int
+
+const (
+	JSONLines RecordFormat = iota
+	Binary
+)
+
+// Record is a single event read from a consumer, either PartitionEvent or
+// MessageEvent is set.
+type Record struct {
+	Time time.Time
+	PartitionEvent *turing.PartitionEvent
+	MessageEvent *turing.MessageEvent
+}
+
+type RecordWriter interface {
+	Write(record Record) error
+	Close() error
+}
+
+// RecordReader returns io.EOF once every record has been read.
+type RecordReader interface {
+	Read() (Record, error)
+	Close() error
+}
+
+type jsonHeader struct {
+	Key string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type jsonRecord struct {
+	Time time.Time `json:"time"`
+	Type string `json:"type"`
+	Event string `json:"event,omitempty"`
+	Topic string `json:"topic"`
+	Partition int64 `json:"partition"`
+	Offset int64 `json:"offset,omitempty"`
+	Key []byte `json:"key"`
+	Value []byte `json:"value"`
+	Headers []jsonHeader `json:"headers,omitempty"`
+}
+
+var partitionEventNames = map[int]string{
+	turing.PartitionCreated: "created",
+	turing.PartitionDestroyed: "destroyed",
+	turing.PartitionEnd: "end",
+}
+
+// Binary recordings start with magic "TREC" and a version byte, followed by
+// records of:
+//
+//	kind byte (0 = end) | time int64 (unix nano) | topic | partition varint
+//	partition record: event type byte
+//	message record: offset varint | key | value | header count | headers
+//
+// Strings are written as a uvarint length followed by the bytes. Keys and
+// values are written as length + 1 so that nil can be told apart, 0 is nil.
+const (
+	recordMagic = "TREC"
+	recordVersion = 1
+	recordMaxSize = 64 * 1024 * 1024
+)
+
+const (
+	recordEnd byte = iota
+	recordPartition
+	recordMessage
+)
+
+type recordFile struct {
+	closer io.Closer
+}
+
+func (rf recordFile) close() error {
+	if rf.closer != nil {
+		return rf.closer.Close()
+	}
+	return nil
+}
+
+func asCloser(v interface{}) recordFile {
+	closer, _ := v.(io.Closer)
+	return recordFile{
+		closer: closer,
+	}
+}
+
+type jsonRecordWriter struct {
+	w *bufio.Writer
+	encoder *json.Encoder
+	file recordFile
+}
+
+func (jrw *jsonRecordWriter) Write(record Record) error {
+	jr := jsonRecord{
+		Time: record.Time,
+	}
+	if pe := record.PartitionEvent; pe != nil {
+		jr.Type = "partition"
+		jr.Event = partitionEventNames[pe.Type]
+		jr.Topic = pe.Topic
+		jr.Partition = pe.Id
+	} else if me := record.MessageEvent; me != nil {
+		jr.Type = "message"
+		jr.Topic = me.Topic
+		jr.Partition = me.PartitionId
+		jr.Offset = me.Offset
+		jr.Key = me.Key
+		jr.Value = me.Value
+		for _, h := range me.Headers {
+			jr.Headers = append(jr.Headers, jsonHeader{
+				Key: h.Key,
+				Value: h.Value,
+			})
+		}
+	} else {
+		return turing.InvalidRecordingError
+	}
+
+	return jrw.encoder.Encode(jr)
+}
+
+func (jrw *jsonRecordWriter) Close() error {
+	if err := jrw.w.Flush(); err != nil {
+		jrw.file.close()
+		return err
+	}
+	return jrw.file.close()
+}
+
+type jsonRecordReader struct {
+	scanner *bufio.Scanner
+	file recordFile
+}
+
+func (jrr *jsonRecordReader) Read() (Record, error) {
+	for jrr.scanner.Scan() {
+		line := jrr.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var jr jsonRecord
+		if err := json.Unmarshal(line, &jr); err != nil {
+			return Record{}, err
+		}
+
+		record := Record{
+			Time: jr.Time,
+		}
+		switch jr.Type {
+		case "partition":
+			eventType := -1
+			for t, name := range partitionEventNames {
+				if name == jr.Event {
+					eventType = t
+				}
+			}
+			if eventType < 0 {
+				return Record{}, turing.InvalidRecordingError
+			}
+			record.PartitionEvent = &turing.PartitionEvent{
+				Type: eventType,
+				Topic: jr.Topic,
+				Id: jr.Partition,
+			}
+		case "message":
+			var headers []turing.Header
+			for _, h := range jr.Headers {
+				headers = append(headers, turing.Header{
+					Key: h.Key,
+					Value: h.Value,
+				})
+			}
+			record.MessageEvent = &turing.MessageEvent{
+				Topic: jr.Topic,
+				PartitionId: jr.Partition,
+				Offset: jr.Offset,
+				Key: jr.Key,
+				Value: jr.Value,
+				Headers: headers,
+			}
+		default:
+			return Record{}, turing.InvalidRecordingError
+		}
+		return record, nil
+	}
+
+	if err := jrr.scanner.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+func (jrr *jsonRecordReader) Close() error {
+	return jrr.file.close()
+}
+
+type binaryRecordWriter struct {
+	w *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	file recordFile
+}
+
+func (brw *binaryRecordWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(brw.buf[:], v)
+	brw.w.Write(brw.buf[:n])
+}
+
+func (brw *binaryRecordWriter) writeVarint(v int64) {
+	n := binary.PutVarint(brw.buf[:], v)
+	brw.w.Write(brw.buf[:n])
+}
+
+func (brw *binaryRecordWriter) writeString(s string) {
+	brw.writeUvarint(uint64(len(s)))
+	brw.w.WriteString(s)
+}
+
+func (brw *binaryRecordWriter) writeBytes(b []byte) {
+	if b == nil {
+		brw.writeUvarint(0)
+		return
+	}
+	brw.writeUvarint(uint64(len(b)) + 1)
+	brw.w.Write(b)
+}
+
+func (brw *binaryRecordWriter) writeHeader(kind byte, t time.Time, topic string, partition int64) {
+	brw.w.WriteByte(kind)
+	binary.BigEndian.PutUint64(brw.buf[:8], uint64(t.UnixNano()))
+	brw.w.Write(brw.buf[:8])
+	brw.writeString(topic)
+	brw.writeVarint(partition)
+}
+
+func (brw *binaryRecordWriter) Write(record Record) error {
+	if pe := record.PartitionEvent; pe != nil {
+		brw.writeHeader(recordPartition, record.Time, pe.Topic, pe.Id)
+		return brw.w.WriteByte(byte(pe.Type))
+	} else if me := record.MessageEvent; me != nil {
+		brw.writeHeader(recordMessage, record.Time, me.Topic, me.PartitionId)
+		brw.writeVarint(me.Offset)
+		brw.writeBytes(me.Key)
+		brw.writeBytes(me.Value)
+		brw.writeUvarint(uint64(len(me.Headers)))
+		for _, h := range me.Headers {
+			brw.writeString(h.Key)
+			brw.writeBytes(h.Value)
+		}
+		return nil
+	}
+
+	return turing.InvalidRecordingError
+}
+
+func (brw *binaryRecordWriter) Close() error {
+	brw.w.WriteByte(recordEnd)
+	if err := brw.w.Flush(); err != nil {
+		brw.file.close()
+		return err
+	}
+	return brw.file.close()
+}
+
+type binaryRecordReader struct {
+	r *bufio.Reader
+	buf [8]byte
+	file recordFile
+}
+
+func (brr *binaryRecordReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(brr.r)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	if n - 1 > recordMaxSize {
+		return nil, turing.InvalidRecordingError
+	}
+
+	b := make([]byte, n - 1)
+	_, err = io.ReadFull(brr.r, b)
+	return b, err
+}
+
+func (brr *binaryRecordReader) readString() (string, error) {
+	n, err := binary.ReadUvarint(brr.r)
+	if err != nil {
+		return "", err
+	}
+	if n > recordMaxSize {
+		return "", turing.InvalidRecordingError
+	}
+
+	b := make([]byte, n)
+	_, err = io.ReadFull(brr.r, b)
+	return string(b), err
+}
+
+func (brr *binaryRecordReader) readMessage(me *turing.MessageEvent) error {
+	var err error
+	if me.Offset, err = binary.ReadVarint(brr.r); err != nil {
+		return err
+	}
+	if me.Key, err = brr.readBytes(); err != nil {
+		return err
+	}
+	if me.Value, err = brr.readBytes(); err != nil {
+		return err
+	}
+
+	count, err := binary.ReadUvarint(brr.r)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		var h turing.Header
+		if h.Key, err = brr.readString(); err != nil {
+			return err
+		}
+		if h.Value, err = brr.readBytes(); err != nil {
+			return err
+		}
+		me.Headers = append(me.Headers, h)
+	}
+	return nil
+}
+
+func (brr *binaryRecordReader) read() (Record, error) {
+	kind, err := brr.r.ReadByte()
+	if err != nil {
+		return Record{}, err
+	}
+	if kind == recordEnd {
+		return Record{}, io.EOF
+	}
+
+	if _, err := io.ReadFull(brr.r, brr.buf[:]); err != nil {
+		return Record{}, err
+	}
+	record := Record{
+		Time: time.Unix(0, int64(binary.BigEndian.Uint64(brr.buf[:]))),
+	}
+	topic, err := brr.readString()
+	if err != nil {
+		return Record{}, err
+	}
+	partition, err := binary.ReadVarint(brr.r)
+	if err != nil {
+		return Record{}, err
+	}
+
+	switch kind {
+	case recordPartition:
+		eventType, err := brr.r.ReadByte()
+		if err != nil {
+			return Record{}, err
+		}
+		record.PartitionEvent = &turing.PartitionEvent{
+			Type: int(eventType),
+			Topic: topic,
+			Id: partition,
+		}
+	case recordMessage:
+		me := &turing.MessageEvent{
+			Topic: topic,
+			PartitionId: partition,
+		}
+		if err := brr.readMessage(me); err != nil {
+			return Record{}, err
+		}
+		record.MessageEvent = me
+	default:
+		return Record{}, turing.InvalidRecordingError
+	}
+	return record, nil
+}
+
+// Read treats a recording that was cut short, for example because the
+// recorder was never closed, as complete up to its last whole record.
+func (brr *binaryRecordReader) Read() (Record, error) {
+	record, err := brr.read()
+	if err == io.ErrUnexpectedEOF {
+		return Record{}, io.EOF
+	}
+	return record, err
+}
+
+func (brr *binaryRecordReader) Close() error {
+	return brr.file.close()
+}
+
+func NewRecordWriter(w io.Writer, format RecordFormat) (RecordWriter, error) {
+	buffered := bufio.NewWriter(w)
+	switch format {
+	case JSONLines:
+		return &jsonRecordWriter{
+			w: buffered,
+			encoder: json.NewEncoder(buffered),
+			file: asCloser(w),
+		}, nil
+	case Binary:
+		buffered.WriteString(recordMagic)
+		buffered.WriteByte(recordVersion)
+		return &binaryRecordWriter{
+			w: buffered,
+			file: asCloser(w),
+		}, nil
+	default:
+		return nil, turing.InvalidRecordingError
+	}
+}
+
+// NewRecordReader detects the format of the recording read from r.
+func NewRecordReader(r io.Reader) (RecordReader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(len(recordMagic) + 1)
+	if err == nil && string(magic[:len(recordMagic)]) == recordMagic {
+		if magic[len(recordMagic)] != recordVersion {
+			return nil, turing.InvalidRecordingError
+		}
+		buffered.Discard(len(magic))
+		return &binaryRecordReader{
+			r: buffered,
+			file: asCloser(r),
+		}, nil
+	}
+
+	scanner := bufio.NewScanner(buffered)
+	scanner.Buffer(nil, recordMaxSize)
+	return &jsonRecordReader{
+		scanner: scanner,
+		file: asCloser(r),
+	}, nil
+}
+
+func CreateRecordFile(path string, format RecordFormat) (RecordWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := NewRecordWriter(f, format)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func OpenRecordFile(path string) (RecordReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewRecordReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Recorder is a Consumer that writes every event of the consumer it wraps
+// before passing it on. If the wrapped consumer is a Runnable it is run and
+// closed along with the recorder.
+type Recorder struct {
+	consumer turing.Consumer
+	writer RecordWriter
+	clock turing.Clock
+	partitionsChan chan turing.PartitionEvent
+	messagesChan chan turing.MessageEvent
+	closeChan chan struct{}
+	closeOnce sync.Once
+	writerClosed bool
+	writeMutex sync.Mutex
+}
+
+func (r *Recorder) SetClock(clock turing.Clock) {
+	r.clock = clock
+}
+
+func (r *Recorder) PartitionEvent() <-chan turing.PartitionEvent {
+	return r.partitionsChan
+}
+
+func (r *Recorder) MessageEvent() <-chan turing.MessageEvent {
+	return r.messagesChan
+}
+
+func (r *Recorder) Commit(topic string, partition int64, offset int64) {
+	r.consumer.Commit(topic, partition, offset)
+}
+
+func (r *Recorder) Assign(topic string, partition int64, offset int64) {
+	r.consumer.Assign(topic, partition, offset)
+}
+
+func (r *Recorder) Subscribe(topics []string) {
+	r.consumer.Subscribe(topics)
+}
+
+func (r *Recorder) record(record Record) {
+	r.writeMutex.Lock()
+	defer r.writeMutex.Unlock()
+	if r.writerClosed {
+		return
+	}
+
+	record.Time = r.clock.Now()
+	if err := r.writer.Write(record); err != nil {
+		turing.Log.WithError(err).Error("recorder: could not write record")
+	}
+}
+
+func (r *Recorder) Close() {
+	r.closeOnce.Do(func () {
+		close(r.closeChan)
+		if runnable, ok := r.consumer.(turing.Runnable); ok {
+			runnable.Close()
+		}
+
+		r.writeMutex.Lock()
+		defer r.writeMutex.Unlock()
+		r.writerClosed = true
+		if err := r.writer.Close(); err != nil {
+			turing.Log.WithError(err).Error("recorder: could not close recording")
+		}
+	})
+}
+
+func (r *Recorder) Run() error {
+	runnableErr := make(chan error, 1)
+	if runnable, ok := r.consumer.(turing.Runnable); ok {
+		go func () {
+			runnableErr <- runnable.Run()
+		}()
+	}
+
+	for {
+		select {
+		case <- r.closeChan:
+			return nil
+		case err := <- runnableErr:
+			if err != nil {
+				return err
+			}
+		case ev := <- r.consumer.PartitionEvent():
+			r.record(Record{
+				PartitionEvent: &ev,
+			})
+			select {
+			case r.partitionsChan <- ev:
+			case <- r.closeChan:
+				return nil
+			}
+		case msg := <- r.consumer.MessageEvent():
+			r.record(Record{
+				MessageEvent: &msg,
+			})
+			select {
+			case r.messagesChan <- msg:
+			case <- r.closeChan:
+				return nil
+			}
+		}
+	}
+}
+
+func NewRecorder(consumer turing.Consumer, writer RecordWriter) *Recorder {
+	return &Recorder{
+		consumer: consumer,
+		writer: writer,
+		clock: turing.SystemClock,
+		partitionsChan: make(chan turing.PartitionEvent),
+		messagesChan: make(chan turing.MessageEvent),
+		closeChan: make(chan struct{}),
+	}
+}
\ No newline at end of file
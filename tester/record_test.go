@@ -0,0 +1,255 @@
+package tester
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"github.com/areller/turing"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecords() []Record {
+	start := time.Unix(1000, 0)
+	return []Record{
+		{
+			Time: start,
+			PartitionEvent: &turing.PartitionEvent{ Type: turing.PartitionCreated, Topic: "topic", Id: 0 },
+		},
+		{
+			Time: start.Add(time.Second),
+			MessageEvent: &turing.MessageEvent{ Topic: "topic", PartitionId: 0, Offset: 0, Value: []byte("a") },
+		},
+		{
+			Time: start.Add(2 * time.Second),
+			MessageEvent: &turing.MessageEvent{
+				Topic: "topic",
+				PartitionId: 0,
+				Offset: 1,
+				Key: []byte("key"),
+				Value: []byte("b"),
+				Headers: []turing.Header{ { Key: "trace", Value: []byte("id") } },
+			},
+		},
+		{
+			Time: start.Add(3 * time.Second),
+			PartitionEvent: &turing.PartitionEvent{ Type: turing.PartitionEnd, Topic: "topic", Id: 0 },
+		},
+	}
+}
+
+func TestRecordFormats(t *testing.T) {
+	for _, format := range []RecordFormat{ JSONLines, Binary } {
+		var buf bytes.Buffer
+		w, err := NewRecordWriter(&buf, format)
+		assert.Nil(t, err)
+		for _, record := range testRecords() {
+			assert.Nil(t, w.Write(record))
+		}
+		assert.Nil(t, w.Close())
+
+		r, err := NewRecordReader(&buf)
+		assert.Nil(t, err)
+		for _, expected := range testRecords() {
+			record, err := r.Read()
+			assert.Nil(t, err)
+			assert.True(t, expected.Time.Equal(record.Time))
+			assert.Equal(t, expected.PartitionEvent, record.PartitionEvent)
+			assert.Equal(t, expected.MessageEvent, record.MessageEvent)
+		}
+		_, err = r.Read()
+		assert.Equal(t, io.EOF, err)
+	}
+
+	_, err := NewRecordReader(bytes.NewReader([]byte("TREC\x09")))
+	assert.Equal(t, turing.InvalidRecordingError, err)
+	r, _ := NewRecordReader(bytes.NewReader([]byte("{\"type\":\"other\"}\n")))
+	_, err = r.Read()
+	assert.Equal(t, turing.InvalidRecordingError, err)
+}
+
+func TestRecordFormatsKeepNilAndEmpty(t *testing.T) {
+	for _, format := range []RecordFormat{ JSONLines, Binary } {
+		var buf bytes.Buffer
+		w, _ := NewRecordWriter(&buf, format)
+		assert.Nil(t, w.Write(Record{
+			Time: time.Unix(0, 0),
+			MessageEvent: &turing.MessageEvent{ Topic: "topic", Key: []byte{}, Value: nil },
+		}))
+		assert.Nil(t, w.Close())
+
+		r, err := NewRecordReader(&buf)
+		assert.Nil(t, err)
+		record, err := r.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{}, record.MessageEvent.Key)
+		assert.Nil(t, record.MessageEvent.Value)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.bin")
+	w, err := CreateRecordFile(path, Binary)
+	assert.Nil(t, err)
+
+	broker := NewBroker()
+	broker.CreateTopic("topic", 2)
+	recorder := NewRecorder(broker.NewConsumer("group"), w)
+	rp := runProcessor(t, recorder, recorder, "topic")
+
+	producer := broker.NewProducer()
+	for _, v := range []string{ "a", "b", "c" } {
+		producer.Send("topic", nil, []byte(v))
+	}
+	assert.Eventually(t, func () bool {
+		return len(rp.received()) == 3
+	}, time.Second, 5 * time.Millisecond)
+	rp.sp.Close()
+
+	r, err := OpenRecordFile(path)
+	assert.Nil(t, err)
+	var partitions, messages int
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		if record.PartitionEvent != nil {
+			partitions++
+		} else {
+			messages++
+		}
+	}
+	assert.Equal(t, 2, partitions)
+	assert.Equal(t, 3, messages)
+}
+
+func writeRecording(t *testing.T, records []Record) RecordReader {
+	var buf bytes.Buffer
+	w, _ := NewRecordWriter(&buf, JSONLines)
+	for _, record := range records {
+		assert.Nil(t, w.Write(record))
+	}
+	w.Close()
+
+	r, err := NewRecordReader(&buf)
+	assert.Nil(t, err)
+	return r
+}
+
+func messageRecords(topic string, partition int64, values ...string) []Record {
+	var records []Record
+	for i, v := range values {
+		records = append(records, Record{
+			Time: time.Unix(int64(i), 0),
+			MessageEvent: &turing.MessageEvent{
+				Topic: topic,
+				PartitionId: partition,
+				Offset: int64(i),
+				Value: []byte(v),
+			},
+		})
+	}
+	return records
+}
+
+func TestReplayer(t *testing.T) {
+	records := append(testRecords(), messageRecords("other", 0, "x")...)
+	replayer := NewReplayer(writeRecording(t, records))
+	rp := runProcessor(t, replayer, replayer, "topic")
+	defer rp.sp.Close()
+
+	<- replayer.Done()
+	assert.Eventually(t, func () bool {
+		off, _ := replayer.Committed("topic", 0)
+		return off == 2
+	}, time.Second, 5 * time.Millisecond)
+	assert.Equal(t, []string{ "a", "b" }, rp.received())
+}
+
+func TestReplayerFilters(t *testing.T) {
+	records := append(messageRecords("topic", 0, "a", "b", "c", "d"), messageRecords("topic", 1, "e", "f")...)
+	replayer := NewReplayer(writeRecording(t, records))
+	replayer.SetPartitions("topic", 0)
+	replayer.SetOffsetRange("topic", 0, 1, 3)
+	rp := runProcessor(t, replayer, replayer, "topic")
+	defer rp.sp.Close()
+
+	<- replayer.Done()
+	assert.Eventually(t, func () bool {
+		off, _ := replayer.Committed("topic", 0)
+		return off == 3
+	}, time.Second, 5 * time.Millisecond)
+	assert.Equal(t, []string{ "b", "c" }, rp.received())
+	_, ok := replayer.Committed("topic", 1)
+	assert.False(t, ok)
+}
+
+func TestReplayerResumesFromCommitted(t *testing.T) {
+	replayer := NewReplayer(writeRecording(t, messageRecords("topic", 0, "a", "b", "c", "d")))
+	replayer.Commit("topic", 0, 1)
+	rp := runProcessor(t, replayer, replayer, "topic")
+	defer rp.sp.Close()
+
+	<- replayer.Done()
+	assert.Eventually(t, func () bool {
+		off, _ := replayer.Committed("topic", 0)
+		return off == 4
+	}, time.Second, 5 * time.Millisecond)
+	assert.Equal(t, []string{ "c", "d" }, rp.received())
+}
+
+func TestReplayerTiming(t *testing.T) {
+	clock := NewVirtualClock(time.Unix(0, 0))
+	replayer := NewReplayer(writeRecording(t, messageRecords("topic", 0, "a", "b", "c")))
+	replayer.SetClock(clock)
+	replayer.SetSpeed(2)
+	replayer.SetMaxDelay(time.Minute)
+	replayer.Subscribe([]string{ "topic" })
+	go replayer.Run()
+	defer replayer.Close()
+
+	var mutex sync.Mutex
+	var received []time.Time
+	stop := make(chan struct{})
+	defer close(stop)
+	go func () {
+		for {
+			select {
+			case <- stop:
+				return
+			case ev := <- replayer.PartitionEvent():
+				replayer.Assign(ev.Topic, ev.Id, turing.OffsetStored)
+			case <- replayer.MessageEvent():
+				mutex.Lock()
+				received = append(received, clock.Now())
+				mutex.Unlock()
+			}
+		}
+	}()
+
+	waitFor := func (n int) {
+		assert.Eventually(t, func () bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return len(received) == n
+		}, time.Second, time.Millisecond)
+	}
+
+	waitFor(1)
+	clock.WaitForTimers(1)
+	clock.Advance(400 * time.Millisecond)
+	clock.Advance(100 * time.Millisecond)
+	waitFor(2)
+	clock.WaitForTimers(1)
+	clock.Advance(500 * time.Millisecond)
+	<- replayer.Done()
+	waitFor(3)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal(t, []time.Time{ time.Unix(0, 0), time.Unix(0, 500 * int64(time.Millisecond)), time.Unix(1, 0) }, received)
+}
\ No newline at end of file
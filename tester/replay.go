@@ -0,0 +1,329 @@
+package tester
+
+import (
+	"io"
+	"sync"
+	"time"
+	"github.com/areller/turing"
+)
+
+type offsetRange struct {
+	from int64
+	to int64
+}
+
+type replayPartition struct {
+	created bool
+	assigned bool
+	offset int64
+}
+
+// Replayer is a Consumer that feeds a recording to a processor. Partitions
+// are announced as in the recording, and one is created on the fly if a
+// message arrives for a partition that was never announced. Messages of a
+// partition are held back until it has been assigned, and when it is
+// assigned an explicit offset the ones before it are skipped.
+type Replayer struct {
+	reader RecordReader
+	clock turing.Clock
+	speed float64
+	maxDelay time.Duration
+	topics map[string]bool
+	partitionFilter map[string]map[int64]bool
+	offsetFilter map[TopicPartition]offsetRange
+	partitions map[TopicPartition]*replayPartition
+	committed map[TopicPartition]int64
+	changed *sync.Cond
+	mutex sync.Mutex
+	partitionsChan chan turing.PartitionEvent
+	messagesChan chan turing.MessageEvent
+	doneChan chan struct{}
+	closeChan chan struct{}
+	closeOnce sync.Once
+}
+
+// SetSpeed controls the pace of the replay. With a speed of 1 the gaps
+// between records are preserved, 10 replays ten times faster and 0, the
+// default, replays without waiting at all.
+func (r *Replayer) SetSpeed(speed float64) {
+	r.speed = speed
+}
+
+// SetMaxDelay caps the wait between two records, compressing idle periods.
+func (r *Replayer) SetMaxDelay(delay time.Duration) {
+	r.maxDelay = delay
+}
+
+func (r *Replayer) SetClock(clock turing.Clock) {
+	r.clock = clock
+}
+
+// SetPartitions restricts the replay of topic to the given partitions.
+func (r *Replayer) SetPartitions(topic string, partitions ...int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.partitionFilter[topic] = make(map[int64]bool)
+	for _, p := range partitions {
+		r.partitionFilter[topic][p] = true
+	}
+}
+
+// SetOffsetRange restricts the messages replayed for a partition to offsets
+// from from up to but excluding to, a negative to leaves the range open.
+func (r *Replayer) SetOffsetRange(topic string, partition int64, from int64, to int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.offsetFilter[TopicPartition{ Topic: topic, Partition: partition }] = offsetRange{
+		from: from,
+		to: to,
+	}
+}
+
+func (r *Replayer) PartitionEvent() <-chan turing.PartitionEvent {
+	return r.partitionsChan
+}
+
+func (r *Replayer) MessageEvent() <-chan turing.MessageEvent {
+	return r.messagesChan
+}
+
+func (r *Replayer) Subscribe(topics []string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.topics = make(map[string]bool)
+	for _, topic := range topics {
+		r.topics[topic] = true
+	}
+}
+
+// Assign starts the replay of a partition at offset. OffsetStored and
+// OffsetNone resume after the last commit, any other negative offset replays
+// the partition from its start.
+func (r *Replayer) Assign(topic string, partition int64, offset int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	tp := TopicPartition{ Topic: topic, Partition: partition }
+	rp, ok := r.partitions[tp]
+	if !ok || !rp.created {
+		return
+	}
+
+	if offset == turing.OffsetStored || offset == turing.OffsetNone {
+		if committed, ok := r.committed[tp]; ok {
+			offset = committed
+		}
+	}
+
+	rp.assigned = true
+	rp.offset = offset
+	r.changed.Broadcast()
+}
+
+func (r *Replayer) Commit(topic string, partition int64, offset int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.committed[TopicPartition{ Topic: topic, Partition: partition }] = offset + 1
+}
+
+// Committed returns the offset of the next message to consume, as Kafka does.
+func (r *Replayer) Committed(topic string, partition int64) (int64, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	off, ok := r.committed[TopicPartition{ Topic: topic, Partition: partition }]
+	return off, ok
+}
+
+// Done is closed once every record has been handed to the processor.
+func (r *Replayer) Done() <-chan struct{} {
+	return r.doneChan
+}
+
+func (r *Replayer) accepts(topic string, partition int64) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.topics != nil && !r.topics[topic] {
+		return false
+	}
+	if partitions, ok := r.partitionFilter[topic]; ok && !partitions[partition] {
+		return false
+	}
+	return true
+}
+
+func (r *Replayer) acceptsOffset(msg turing.MessageEvent) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	rng, ok := r.offsetFilter[TopicPartition{ Topic: msg.Topic, Partition: msg.PartitionId }]
+	return !ok || (msg.Offset >= rng.from && (rng.to < 0 || msg.Offset < rng.to))
+}
+
+func (r *Replayer) partition(tp TopicPartition) *replayPartition {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	rp, ok := r.partitions[tp]
+	if !ok {
+		rp = &replayPartition{}
+		r.partitions[tp] = rp
+	}
+	return rp
+}
+
+func (r *Replayer) created(topic string, partition int64) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	rp, ok := r.partitions[TopicPartition{ Topic: topic, Partition: partition }]
+	return ok && rp.created
+}
+
+func (r *Replayer) sendPartitionEvent(ev turing.PartitionEvent) bool {
+	rp := r.partition(TopicPartition{ Topic: ev.Topic, Partition: ev.Id })
+	r.mutex.Lock()
+	switch ev.Type {
+	case turing.PartitionCreated:
+		rp.created = true
+		rp.assigned = false
+	case turing.PartitionDestroyed:
+		rp.created = false
+		rp.assigned = false
+	}
+	r.mutex.Unlock()
+
+	select {
+	case r.partitionsChan <- ev:
+		return true
+	case <- r.closeChan:
+		return false
+	}
+}
+
+// waitAssigned blocks until rp is assigned, it returns false if the replayer
+// was closed in the meantime.
+func (r *Replayer) waitAssigned(rp *replayPartition) (int64, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for !rp.assigned {
+		select {
+		case <- r.closeChan:
+			return 0, false
+		default:
+		}
+		r.changed.Wait()
+	}
+	return rp.offset, true
+}
+
+func (r *Replayer) sendMessage(msg turing.MessageEvent) bool {
+	tp := TopicPartition{ Topic: msg.Topic, Partition: msg.PartitionId }
+	rp := r.partition(tp)
+	if !r.created(msg.Topic, msg.PartitionId) && !r.sendPartitionEvent(turing.PartitionEvent{
+		Type: turing.PartitionCreated,
+		Topic: msg.Topic,
+		Id: msg.PartitionId,
+	}) {
+		return false
+	}
+
+	offset, ok := r.waitAssigned(rp)
+	if !ok {
+		return false
+	} else if offset >= 0 && msg.Offset < offset {
+		return true
+	}
+
+	select {
+	case r.messagesChan <- msg:
+		return true
+	case <- r.closeChan:
+		return false
+	}
+}
+
+func (r *Replayer) wait(delay time.Duration) bool {
+	if r.speed <= 0 || delay <= 0 {
+		return true
+	}
+
+	delay = time.Duration(float64(delay) / r.speed)
+	if r.maxDelay > 0 && delay > r.maxDelay {
+		delay = r.maxDelay
+	}
+
+	timer := r.clock.NewTimer(delay)
+	select {
+	case <- r.closeChan:
+		timer.Stop()
+		return false
+	case <- timer.C():
+		return true
+	}
+}
+
+func (r *Replayer) Close() {
+	r.closeOnce.Do(func () {
+		close(r.closeChan)
+		r.mutex.Lock()
+		r.changed.Broadcast()
+		r.mutex.Unlock()
+	})
+}
+
+func (r *Replayer) Run() error {
+	defer r.reader.Close()
+
+	var last time.Time
+	for {
+		record, err := r.reader.Read()
+		if err == io.EOF {
+			close(r.doneChan)
+			<- r.closeChan
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var topic string
+		var partition int64
+		if record.PartitionEvent != nil {
+			topic, partition = record.PartitionEvent.Topic, record.PartitionEvent.Id
+		} else {
+			topic, partition = record.MessageEvent.Topic, record.MessageEvent.PartitionId
+		}
+		if !r.accepts(topic, partition) || (record.MessageEvent != nil && !r.acceptsOffset(*record.MessageEvent)) {
+			continue
+		} else if record.PartitionEvent != nil && record.PartitionEvent.Type == turing.PartitionDestroyed && !r.created(topic, partition) {
+			continue
+		}
+
+		if !last.IsZero() && !r.wait(record.Time.Sub(last)) {
+			return nil
+		}
+		last = record.Time
+
+		var ok bool
+		if record.PartitionEvent != nil {
+			ok = r.sendPartitionEvent(*record.PartitionEvent)
+		} else {
+			ok = r.sendMessage(*record.MessageEvent)
+		}
+		if !ok {
+			return nil
+		}
+	}
+}
+
+func NewReplayer(reader RecordReader) *Replayer {
+	r := &Replayer{
+		reader: reader,
+		clock: turing.SystemClock,
+		partitionFilter: make(map[string]map[int64]bool),
+		offsetFilter: make(map[TopicPartition]offsetRange),
+		partitions: make(map[TopicPartition]*replayPartition),
+		committed: make(map[TopicPartition]int64),
+		partitionsChan: make(chan turing.PartitionEvent),
+		messagesChan: make(chan turing.MessageEvent),
+		doneChan: make(chan struct{}),
+		closeChan: make(chan struct{}),
+	}
+	r.changed = sync.NewCond(&r.mutex)
+	return r
+}
\ No newline at end of file
@@ -1,52 +1,265 @@
 package turing
 
 import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
+	"github.com/robfig/cron/v3"
 )
 
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (es everySchedule) Next(t time.Time) time.Time {
+	return t.Add(es.interval)
+}
+
+// Every schedules a tick every interval, it panics if interval is not
+// positive.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("turing: non-positive interval for Every")
+	}
+
+	return everySchedule{
+		interval: interval,
+	}
+}
+
+func ParseCron(expression string) (Schedule, error) {
+	return cron.ParseStandard(expression)
+}
+
 type Ticker struct {
 	closeChan chan struct{}
-	tticker *time.Ticker
+	closeOnce sync.Once
+	schedule Schedule
+	initialDelay time.Duration
+	jitter time.Duration
+	retryDelay time.Duration
+	skipIfRunning bool
+	running int32
+	lock *Lock
+	clock Clock
 	todo func(obj interface{}) (err error, moveOn bool)
 	obj interface{}
 }
 
+func (t *Ticker) SetInitialDelay(delay time.Duration) {
+	t.initialDelay = delay
+}
+
+func (t *Ticker) SetJitter(jitter time.Duration) {
+	t.jitter = jitter
+}
+
+func (t *Ticker) SetRetryDelay(delay time.Duration) {
+	t.retryDelay = delay
+}
+
+func (t *Ticker) SetSkipIfRunning(skip bool) {
+	t.skipIfRunning = skip
+}
+
+// SetSingleInstance makes the ticker run its job only while holding a lock on
+// key, so that a tick is handled by a single instance. The lock is renewed
+// while the job runs and released once it is done.
+func (t *Ticker) SetSingleInstance(store LeaseKVStore, key string, ttl time.Duration) {
+	t.lock = NewLock(store, key, ttl)
+	t.lock.SetClock(t.clock)
+}
+
+func (t *Ticker) SetClock(clock Clock) {
+	t.clock = clock
+	if t.lock != nil {
+		t.lock.SetClock(clock)
+	}
+}
+
 func (t *Ticker) Close() {
-	close(t.closeChan)
+	t.closeOnce.Do(func () {
+		close(t.closeChan)
+	})
+}
+
+func (t *Ticker) withJitter(next time.Time) time.Time {
+	if t.jitter <= 0 {
+		return next
+	}
+
+	return next.Add(time.Duration(rand.Int63n(int64(t.jitter))))
+}
+
+func (t *Ticker) acquire() bool {
+	if t.lock == nil {
+		return true
+	}
+
+	ok, err := t.lock.TryAcquire()
+	if err != nil {
+		Log.WithError(err).WithFields(LogFields{
+			"key": t.lock.Key(),
+		}).Error("Could not acquire ticker lock")
+		return false
+	}
+
+	return ok
+}
+
+// renew keeps the lock alive until stop is closed or the lock is lost.
+func (t *Ticker) renew(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+	for {
+		timer := t.clock.NewTimer(t.lock.ttl / 3)
+		select {
+		case <- stop:
+			timer.Stop()
+			return
+		case <- timer.C():
+			err := t.lock.Renew()
+			if err != nil {
+				Log.WithError(err).WithFields(LogFields{
+					"key": t.lock.Key(),
+				}).Error("Could not renew ticker lock")
+			}
+			if err == LockNotHeldError {
+				return
+			}
+		}
+	}
+}
+
+func (t *Ticker) release() {
+	if err := t.lock.Release(); err != nil && err != LockNotHeldError {
+		Log.WithError(err).WithFields(LogFields{
+			"key": t.lock.Key(),
+		}).Error("Could not release ticker lock")
+	}
+}
+
+// execute runs todo until it moves on.
+func (t *Ticker) execute() {
+	if !t.acquire() {
+		return
+	}
+
+	if t.lock != nil {
+		stop, done := make(chan struct{}), make(chan struct{})
+		go t.renew(stop, done)
+		defer func () {
+			close(stop)
+			<- done
+			t.release()
+		}()
+	}
+
+	for {
+		// Another instance may run the job once the lock is lost, so it is not
+		// retried without it.
+		if t.lock != nil && !t.lock.Held() {
+			Log.WithFields(LogFields{
+				"key": t.lock.Key(),
+			}).Warn("Ticker lock was lost, not retrying the job")
+			return
+		}
+
+		err, moveOn := t.todo(t.obj)
+		if err == FatalError {
+			Log.WithError(err).Panic("Exiting due to a fatal error")
+			return
+		} else if err != nil {
+			Log.WithError(err).Error("Could not process message, handler returned a non-fatal error")
+		}
+
+		if moveOn {
+			return
+		}
+
+		timer := t.clock.NewTimer(t.retryDelay)
+		select {
+		case <- t.closeChan:
+			timer.Stop()
+			return
+		case <- timer.C():
+		}
+	}
+}
+
+func (t *Ticker) work(jobs chan struct{}, done chan struct{}) {
+	defer close(done)
+	for range jobs {
+		atomic.StoreInt32(&t.running, 1)
+		t.execute()
+		atomic.StoreInt32(&t.running, 0)
+	}
 }
 
 func (t *Ticker) Run() error {
+	jobs := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go t.work(jobs, done)
+
+	now := t.clock.Now()
+	next := t.schedule.Next(now)
+	if t.initialDelay > 0 {
+		next = now.Add(t.initialDelay)
+	}
+
+	timer := t.clock.NewTimer(t.withJitter(next).Sub(now))
+	defer timer.Stop()
+
 	for {
 		select {
-		case <-t.closeChan:
+		case <- t.closeChan:
+			close(jobs)
+			<- done
 			return nil
-		case <-t.tticker.C:
-			{
-				for {
-					err, moveOn := t.todo(t.obj)
-					if err == FatalError {
-						Log.WithError(err).Panic("Exiting due to a fatal error")
-						return nil
-					} else {
-						if err != nil {
-							Log.WithError(err).Error("Could not process message, handler returned a non-fatal error")
-						}
-
-						if moveOn {
-							break
-						}
-					}
+		case <- timer.C():
+			if t.skipIfRunning && atomic.LoadInt32(&t.running) == 1 {
+				Log.Debug("Skipping tick, previous run is still in progress")
+			} else {
+				select {
+				case jobs <- struct{}{}:
+				default:
 				}
 			}
+
+			now = t.clock.Now()
+			next = t.schedule.Next(next)
+			if next.Before(now) {
+				next = t.schedule.Next(now)
+			}
+			timer.Reset(t.withJitter(next).Sub(now))
 		}
 	}
 }
 
-func NewTicker(duration time.Duration, object interface{}, todo func (obj interface{}) (error, bool)) *Ticker {
+func NewScheduledTicker(schedule Schedule, object interface{}, todo func (obj interface{}) (error, bool)) *Ticker {
 	return &Ticker{
 		closeChan: make(chan struct{}),
-		tticker: time.NewTicker(duration),
+		schedule: schedule,
+		retryDelay: 100 * time.Millisecond,
+		clock: SystemClock,
 		todo: todo,
 		obj: object,
 	}
+}
+
+func NewCronTicker(expression string, object interface{}, todo func (obj interface{}) (error, bool)) (*Ticker, error) {
+	schedule, err := ParseCron(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScheduledTicker(schedule, object, todo), nil
+}
+
+func NewTicker(duration time.Duration, object interface{}, todo func (obj interface{}) (error, bool)) *Ticker {
+	return NewScheduledTicker(Every(duration), object, todo)
 }
\ No newline at end of file
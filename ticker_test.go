@@ -3,6 +3,8 @@ package turing
 import (
 	_ "math"
 	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
 	"time"
 	"testing"
 )
@@ -27,7 +29,7 @@ func TestTicking(t *testing.T) {
 	}
 
 	tick.Close()
-	res := tryWithTimeout(time.Second, func () {
+	res := tryWithTimeout(SystemClock, time.Second, func () {
 		<- rChan
 	})
 
@@ -44,10 +46,151 @@ func TestTickerObject(t *testing.T) {
 
 	go tick.Run()
 	var matchingObject interface{}
-	res := tryWithTimeout(time.Second, func () {
+	res := tryWithTimeout(SystemClock, time.Second, func () {
 		matchingObject = <- ch
 	})
 
 	assert.True(t, res)
 	assert.Equal(t, object, matchingObject)
+}
+
+func TestTickerRetryDelay(t *testing.T) {
+	calls := int32(0)
+	tick := NewTicker(time.Hour, nil, func (obj interface{}) (error, bool) {
+		atomic.AddInt32(&calls, 1)
+		return nil, false
+	})
+	tick.SetInitialDelay(time.Millisecond)
+	tick.SetRetryDelay(50 * time.Millisecond)
+
+	go tick.Run()
+	time.Sleep(220 * time.Millisecond)
+	tick.Close()
+
+	c := atomic.LoadInt32(&calls)
+	assert.True(t, c >= 3 && c <= 6)
+}
+
+func TestTickerSkipIfRunning(t *testing.T) {
+	calls := int32(0)
+	tick := NewTicker(10 * time.Millisecond, nil, func (obj interface{}) (error, bool) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		return nil, true
+	})
+	tick.SetSkipIfRunning(true)
+
+	go tick.Run()
+	time.Sleep(150 * time.Millisecond)
+	tick.Close()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestTickerCron(t *testing.T) {
+	_, err := NewCronTicker("not a cron", nil, nil)
+	assert.NotNil(t, err)
+
+	schedule, err := ParseCron("*/5 * * * *")
+	assert.Nil(t, err)
+
+	from := time.Date(2020, 1, 1, 10, 1, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2020, 1, 1, 10, 5, 0, 0, time.UTC), schedule.Next(from))
+}
+
+func TestTickerSingleInstance(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	interval := 100 * time.Millisecond
+	start := time.Now()
+	var mutex sync.Mutex
+	runs := make(map[int64]int)
+	var tickers []*Ticker
+	for i := 0; i < 3; i++ {
+		tick := NewTicker(interval, nil, func (obj interface{}) (error, bool) {
+			mutex.Lock()
+			runs[int64(time.Since(start) / interval)]++
+			mutex.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			return nil, true
+		})
+		tick.SetSingleInstance(store, "ticker_lock", time.Minute)
+		tickers = append(tickers, tick)
+		go tick.Run()
+	}
+
+	time.Sleep(450 * time.Millisecond)
+	for _, tick := range tickers {
+		tick.Close()
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.True(t, len(runs) >= 3)
+	for tick, n := range runs {
+		assert.Equal(t, 1, n, "runs of tick %d", tick)
+	}
+}
+
+func TestTickerSingleInstanceRenewsLock(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	tick := NewTicker(time.Hour, nil, func (obj interface{}) (error, bool) {
+		close(running)
+		<- release
+		return nil, true
+	})
+	tick.SetInitialDelay(time.Millisecond)
+	tick.SetSingleInstance(store, "ticker_lock", 30 * time.Millisecond)
+	go tick.Run()
+	defer tick.Close()
+
+	<- running
+	time.Sleep(100 * time.Millisecond)
+	ok, err := store.SetNX("ticker_lock", "other", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	close(release)
+	assert.Eventually(t, func () bool {
+		ok, _ := store.SetNX("ticker_lock", "other", time.Minute)
+		return ok
+	}, time.Second, 5 * time.Millisecond)
+}
+
+func TestTickerSingleInstanceStopsRetryingWithoutLock(t *testing.T) {
+	store := NewKVStoreMemory()
+	defer store.Close()
+
+	attempts := int32(0)
+	tick := NewTicker(time.Hour, nil, func (obj interface{}) (error, bool) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			// Another instance takes over the lock.
+			store.Set("ticker_lock", "other")
+		}
+		return GeneralError, false
+	})
+	tick.SetInitialDelay(time.Millisecond)
+	tick.SetRetryDelay(50 * time.Millisecond)
+	tick.SetSingleInstance(store, "ticker_lock", 30 * time.Millisecond)
+	go tick.Run()
+	defer tick.Close()
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	val, _ := store.Get("ticker_lock")
+	assert.Equal(t, "other", val)
+}
+
+func TestEveryRejectsNonPositiveInterval(t *testing.T) {
+	assert.Panics(t, func () {
+		Every(0)
+	})
+	assert.Panics(t, func () {
+		NewTicker(-time.Second, nil, nil)
+	})
 }
\ No newline at end of file
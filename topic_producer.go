@@ -1,5 +1,12 @@
 package turing
 
+import (
+	"context"
+	"time"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
 type TopicProducer struct {
 	producer Producer
 	codec Codec
@@ -7,13 +14,50 @@ type TopicProducer struct {
 	Topic string
 }
 
+func (tp *TopicProducer) produce(ctx context.Context, encoded EncodedKV) error {
+	if contextProducer, ok := tp.producer.(ContextProducer); ok {
+		return contextProducer.SendContext(ctx, tp.Topic, encoded.Key, encoded.Value, encoded.Headers)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if headerProducer, ok := tp.producer.(HeaderProducer); ok && len(encoded.Headers) > 0 {
+		return headerProducer.SendWithHeaders(tp.Topic, encoded.Key, encoded.Value, encoded.Headers)
+	}
+
+	return tp.producer.Send(tp.Topic, encoded.Key, encoded.Value)
+}
+
+func (tp *TopicProducer) send(ctx context.Context, encoded EncodedKV) error {
+	if GetTracer() == nil {
+		return tp.produce(ctx, encoded)
+	}
+
+	ctx, span := startSpan(ctx, tp.Topic + " send", trace.SpanKindProducer,
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", tp.Topic))
+
+	encoded.Headers = InjectTraceHeaders(ctx, encoded.Headers)
+	err := tp.produce(ctx, encoded)
+	endSpan(span, err)
+	return err
+}
+
 func (tp *TopicProducer) Send(key string, msg interface{}) error {
+	return tp.SendContext(context.Background(), key, msg)
+}
+
+func (tp *TopicProducer) SendContext(ctx context.Context, key string, msg interface{}) error {
 	encoded, err := tp.codec.Encode(key, msg)
 	if err != nil {
 		return err
 	}
 
-	err = tp.producer.Send(tp.Topic, encoded.Key, encoded.Value)
+	start := time.Now()
+	err = tp.send(ctx, encoded)
+	GetMetrics().ProducerSent(tp.Topic, time.Since(start), err)
 	return err
 }
 
@@ -10,9 +10,9 @@ func TestProducing(t *testing.T) {
 	tp := NewTopicProducer("myTopic", new(StringCodec), pm)
 
 	tp.Send("myKey", "My Message")
-	msg := <- pm.SentMessages
+	msg := pm.Messages()[0]
 
-	assert.Equal(t, "myTopic", msg.topic)
-	assert.Equal(t, []byte("myKey"), msg.key)
-	assert.Equal(t, []byte("My Message"), msg.value)
+	assert.Equal(t, "myTopic", msg.Topic)
+	assert.Equal(t, []byte("myKey"), msg.Key)
+	assert.Equal(t, []byte("My Message"), msg.Value)
 }
\ No newline at end of file
@@ -0,0 +1,94 @@
+package turing
+
+import (
+	"context"
+	"sync/atomic"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracerHolder struct {
+	tracer trace.Tracer
+}
+
+var currentTracer atomic.Value
+
+func init() {
+	currentTracer.Store(tracerHolder{})
+}
+
+func GetTracer() trace.Tracer {
+	return currentTracer.Load().(tracerHolder).tracer
+}
+
+func SetTracer(tracer trace.Tracer) {
+	currentTracer.Store(tracerHolder{
+		tracer: tracer,
+	})
+}
+
+type headersCarrier struct {
+	headers *[]Header
+}
+
+func (hc headersCarrier) Get(key string) string {
+	for _, h := range *hc.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (hc headersCarrier) Set(key string, value string) {
+	for i, h := range *hc.headers {
+		if h.Key == key {
+			(*hc.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*hc.headers = append(*hc.headers, Header{
+		Key: key,
+		Value: []byte(value),
+	})
+}
+
+func (hc headersCarrier) Keys() []string {
+	keys := make([]string, len(*hc.headers))
+	for i, h := range *hc.headers {
+		keys[i] = h.Key
+	}
+	return keys
+}
+
+func InjectTraceHeaders(ctx context.Context, headers []Header) []Header {
+	otel.GetTextMapPropagator().Inject(ctx, headersCarrier{
+		headers: &headers,
+	})
+	return headers
+}
+
+func ExtractTraceHeaders(ctx context.Context, headers []Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, headersCarrier{
+		headers: &headers,
+	})
+}
+
+func startSpan(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := GetTracer()
+	if tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	return tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != KeyNotExistsError {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
\ No newline at end of file
@@ -0,0 +1,66 @@
+package turing
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracePropagation(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	SetTracer(provider.Tracer("turing"))
+	defer SetTracer(nil)
+
+	pm := NewProducerMock()
+	tp := NewTopicProducer("myTopic", new(StringCodec), pm)
+	assert.Nil(t, tp.Send("myKey", "My Message"))
+	sent := pm.Messages()[0]
+	assert.NotEmpty(t, sent.Headers)
+
+	spanChan := make(chan trace.SpanContext, 1)
+	consumer := NewConsumerMock()
+	sp, _ := NewSimpleProcessor(consumer, nil, []SimpleProcessorTopicDefinition{
+		SimpleProcessorTopicDefinition{
+			Name: "myTopic",
+			Codec: new(StringCodec),
+			Handler: func (ctx SimpleProcessorContext, msg DecodedKV) (error, bool) {
+				spanChan <- ctx.SpanContext()
+				return nil, true
+			},
+		},
+	})
+
+	go sp.Run()
+	defer sp.Close()
+
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 0,
+	})
+	consumer.CreatePartitionEvent(PartitionEvent{
+		Type: PartitionCreated,
+		Topic: "myTopic",
+		Id: 1,
+	})
+	consumer.CreateMessageEvent(MessageEvent{
+		Topic: "myTopic",
+		PartitionId: 0,
+		Offset: 7,
+		Key: sent.Key,
+		Value: sent.Value,
+		Headers: sent.Headers,
+	})
+
+	consumerSpan := <- spanChan
+	producerSpan := exporter.GetSpans()[0]
+	assert.True(t, consumerSpan.IsValid())
+	assert.Equal(t, producerSpan.SpanContext.TraceID(), consumerSpan.TraceID())
+	assert.NotEqual(t, producerSpan.SpanContext.SpanID(), consumerSpan.SpanID())
+}
\ No newline at end of file
@@ -0,0 +1,153 @@
+package turing
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"github.com/golang/protobuf/proto"
+)
+
+type ValueCodec interface {
+	EncodeValue(value interface{}) ([]byte, error)
+	DecodeValue(data []byte, dest interface{}) error
+}
+
+type PlainValueCodec struct {
+
+}
+
+func (pvc *PlainValueCodec) EncodeValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case int:
+		return []byte(strconv.FormatInt(int64(v), 10)), nil
+	case int32:
+		return []byte(strconv.FormatInt(int64(v), 10)), nil
+	case int64:
+		return []byte(strconv.FormatInt(v, 10)), nil
+	case uint64:
+		return []byte(strconv.FormatUint(v, 10)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		return []byte(strconv.FormatBool(v)), nil
+	}
+	return nil, InvalidTypeError
+}
+
+func (pvc *PlainValueCodec) DecodeValue(data []byte, dest interface{}) error {
+	var err error
+	s := string(data)
+	switch d := dest.(type) {
+	case *string:
+		*d = s
+	case *[]byte:
+		*d = append([]byte(nil), data...)
+	case *int:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 64)
+		*d = int(n)
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		*d = int32(n)
+	case *int64:
+		*d, err = strconv.ParseInt(s, 10, 64)
+	case *uint64:
+		*d, err = strconv.ParseUint(s, 10, 64)
+	case *float64:
+		*d, err = strconv.ParseFloat(s, 64)
+	case *bool:
+		*d, err = strconv.ParseBool(s)
+	default:
+		return InvalidTypeError
+	}
+
+	if err != nil {
+		return InvalidValueError
+	}
+	return nil
+}
+
+type JSONValueCodec struct {
+
+}
+
+func (jvc *JSONValueCodec) EncodeValue(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jvc *JSONValueCodec) DecodeValue(data []byte, dest interface{}) error {
+	if err := json.Unmarshal(data, dest); err != nil {
+		return InvalidValueError
+	}
+	return nil
+}
+
+type ProtobufValueCodec struct {
+
+}
+
+func (pvc *ProtobufValueCodec) EncodeValue(value interface{}) ([]byte, error) {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return nil, InvalidTypeError
+	}
+	return proto.Marshal(msg)
+}
+
+func (pvc *ProtobufValueCodec) DecodeValue(data []byte, dest interface{}) error {
+	msg, ok := dest.(proto.Message)
+	if !ok {
+		return InvalidTypeError
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return InvalidValueError
+	}
+	return nil
+}
+
+type messageValueCodec struct {
+	codec Codec
+}
+
+func (mvc *messageValueCodec) EncodeValue(value interface{}) ([]byte, error) {
+	kv, err := mvc.codec.Encode("", value)
+	if err != nil {
+		return nil, err
+	}
+	return kv.Value, nil
+}
+
+func (mvc *messageValueCodec) DecodeValue(data []byte, dest interface{}) error {
+	kv, err := mvc.codec.Decode(nil, data)
+	if err != nil {
+		return err
+	}
+
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		return InvalidTypeError
+	}
+
+	value := reflect.ValueOf(kv.Value)
+	target := destValue.Elem()
+	if value.Kind() == reflect.Ptr && value.Type().Elem().AssignableTo(target.Type()) {
+		value = value.Elem()
+	}
+	if !value.IsValid() || !value.Type().AssignableTo(target.Type()) {
+		return InvalidTypeError
+	}
+
+	target.Set(value)
+	return nil
+}
+
+func ValueCodecFromCodec(codec Codec) ValueCodec {
+	return &messageValueCodec{
+		codec: codec,
+	}
+}
\ No newline at end of file
@@ -0,0 +1,41 @@
+package turing
+
+type KeyEventType int
+
+const (
+	KeySetEvent KeyEventType = iota
+	KeyDeleteEvent
+	KeyExpireEvent
+)
+
+func (ket KeyEventType) String() string {
+	switch ket {
+	case KeySetEvent:
+		return "set"
+	case KeyDeleteEvent:
+		return "delete"
+	case KeyExpireEvent:
+		return "expire"
+	default:
+		return "unknown"
+	}
+}
+
+type KeyEvent struct {
+	Type KeyEventType
+	Key string
+}
+
+type Subscription interface {
+	Events() <-chan KeyEvent
+	Close()
+}
+
+// WatchKVStore publishes changes of keys to subscriptions. Events are
+// buffered per subscription, a subscription that falls behind by more than
+// its buffer is closed rather than dropping events or blocking the store, so
+// once Events is closed the subscriber may have missed changes.
+type WatchKVStore interface {
+	KVStore
+	WatchPattern(pattern string) (Subscription, error)
+}
\ No newline at end of file